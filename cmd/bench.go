@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/eddiezane/hook/pkg/hook"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Flags
+	benchConcurrency int
+	benchRate        string
+	benchDuration    time.Duration
+
+	benchCommand = &cobra.Command{
+		Use:   "bench <hook> <target>",
+		Short: "Load tests a target by firing a hook repeatedly",
+		Long: `bench fires the selected hook at the given url for a fixed duration, either
+at a fixed rate or as fast as possible, and reports latency percentiles, a
+latency histogram, status classes and throughput.
+
+Hooks may use templates such as {{ uuid }} to generate unique values for
+every request.`,
+		Example: "hook bench --rate 100/s --duration 30s @github/push http://localhost:8080",
+		RunE:    bench,
+	}
+)
+
+func init() {
+	benchCommand.Flags().IntVarP(&benchConcurrency, "concurrency", "c", 10, "Number of requests to have in flight at once")
+	benchCommand.Flags().StringVar(&benchRate, "rate", "", "Rate to start requests at, e.g. 50/s. If not specified, requests are sent as fast as possible")
+	benchCommand.Flags().DurationVarP(&benchDuration, "duration", "d", 10*time.Second, "How long to run the test for")
+	rootCmd.AddCommand(benchCommand)
+}
+
+func bench(cmd *cobra.Command, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("incorrect number of arguments provided. expected %d", 2)
+	}
+
+	hooks, err := hook.NewFromPath(args[0])
+	if err != nil {
+		return err
+	}
+
+	pool := &hook.Pool{
+		Concurrency: benchConcurrency,
+		Duration:    benchDuration,
+	}
+	if benchRate != "" {
+		pool.Rate, err = hook.ParseRate(benchRate)
+		if err != nil {
+			return err
+		}
+	}
+
+	report := hook.NewBenchReport()
+	summary := pool.Fire(args[1], hooks, report.Add)
+	report.Duration = summary.Duration
+	report.Print(os.Stdout)
+	return nil
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/eddiezane/hook/pkg/hook"
+	"github.com/spf13/cobra"
+)
+
+var (
+	listCmd = &cobra.Command{
+		Use:   "list [catalog][/prefix]",
+		Short: "Lists the hooks of the catalogs.",
+		Long: `list enumerates the hook files of the configured catalogs, with the number
+of documents, the events they send and their names, after the description
+of each catalog from its catalog.yaml manifest. A catalog and a path
+prefix within it can be given as <catalog>@[prefix] or <catalog>[/prefix],
+and <catalog>@[prefix]#<revision> lists the catalog at a tag, branch or
+commit. Catalogs that have not been fetched yet are cloned first, and
+catalogs that can't be read are listed with their error.`,
+		Example: "hook catalog list @github",
+		Args:    cobra.MaximumNArgs(1),
+		RunE:    list,
+	}
+	listJSON bool
+)
+
+func init() {
+	listCmd.Flags().BoolVar(&listJSON, "json", false, "print the hooks as JSON")
+	catalogCmd.AddCommand(listCmd)
+}
+
+func list(cmd *cobra.Command, args []string) error {
+	arg := ""
+	if len(args) == 1 {
+		arg = args[0]
+	}
+	listings, err := hook.ListCatalogs(arg)
+	if err != nil {
+		return err
+	}
+
+	if !listJSON {
+		err = hook.PrintCatalogListings(cmd.OutOrStdout(), listings)
+	} else {
+		var b []byte
+		if b, err = json.MarshalIndent(listings, "", "  "); err == nil {
+			fmt.Fprintln(cmd.OutOrStdout(), string(b))
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, l := range listings {
+		if l.Error != "" {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d catalogs failed to list", failed)
+	}
+	return nil
+}
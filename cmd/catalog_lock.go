@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/eddiezane/hook/pkg/hook"
+	"github.com/spf13/cobra"
+)
+
+var (
+	lockCmd = &cobra.Command{
+		Use:   "lock [name]...",
+		Short: "Pins catalogs to their cached commits.",
+		Long: `lock records the commit checked out in the cache of each catalog in the
+project's hook.lock, so that everyone firing @github/push sends the same
+payload. Hooks from locked catalogs are read at the locked commit regardless
+of what the cache has checked out. hook.lock is looked up in the working
+directory and its parents, and created in the working directory if there is
+none.
+
+--check doesn't write the lockfile, but fails if the configured catalogs or
+their caches don't match it, e.g. in CI after hook catalog update.`,
+		Example:      "hook catalog lock @",
+		RunE:         lock,
+		SilenceUsage: true,
+	}
+	lockCheck bool
+)
+
+func init() {
+	lockCmd.Flags().BoolVar(&lockCheck, "check", false, "Fail if the caches don't match the lockfile instead of writing it")
+	catalogCmd.AddCommand(lockCmd)
+}
+
+func lock(cmd *cobra.Command, args []string) error {
+	if lockCheck {
+		return checkLock(cmd)
+	}
+	return lockConfig(args...)
+}
+
+// lockConfig locks the named catalogs, or all catalogs if there are none.
+func lockConfig(names ...string) error {
+	rcs, err := hook.GetRemoteConfigs()
+	if err != nil {
+		return err
+	}
+	l, path, err := hook.LoadLockfile()
+	if err != nil {
+		return err
+	}
+	if l == nil {
+		l = &hook.Lockfile{}
+		wd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		path = filepath.Join(wd, hook.LockfileName)
+	}
+
+	if err := l.Lock(rcs, names...); err != nil {
+		return err
+	}
+	log.Printf("writing %s", path)
+	return l.Write(path)
+}
+
+func checkLock(cmd *cobra.Command) error {
+	l, path, err := hook.LoadLockfile()
+	if err != nil {
+		return err
+	}
+	if l == nil {
+		return fmt.Errorf("no %s found", hook.LockfileName)
+	}
+	rcs, err := hook.GetRemoteConfigs()
+	if err != nil {
+		return err
+	}
+	problems, err := l.Check(rcs)
+	if err != nil {
+		return err
+	}
+	for _, p := range problems {
+		fmt.Fprintf(cmd.OutOrStdout(), "%s: %s\n", path, p)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%d catalogs don't match the lockfile", len(problems))
+	}
+	return nil
+}
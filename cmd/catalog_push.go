@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/eddiezane/hook/pkg/hook"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pushCmd = &cobra.Command{
+		Use:   "push <dir> <oci-url>",
+		Short: "Pushes a catalog directory to an OCI registry.",
+		Long: `push packages the hooks in a local catalog directory as an OCI artifact and
+pushes it to a registry, tagged as given by an oci://<registry>/<repository>:<tag>
+URL. Files and directories starting with a dot are left out.
+
+The digest of the artifact is printed. Pushing the same files always results
+in the same digest, and catalogs can be pinned to it with an
+oci://<registry>/<repository>@<digest> URL or a checksum.
+
+Credentials for the registry are read from HOOK_OCI_USERNAME and
+HOOK_OCI_PASSWORD.`,
+		Example:      "hook catalog push ./catalog oci://ghcr.io/acme/hook-catalog:v1",
+		Args:         cobra.ExactArgs(2),
+		RunE:         push,
+		SilenceUsage: true,
+	}
+)
+
+func init() {
+	catalogCmd.AddCommand(pushCmd)
+}
+
+func push(cmd *cobra.Command, args []string) error {
+	digest, err := hook.PushCatalog(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), digest)
+	return nil
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/eddiezane/hook/pkg/hook"
+	"github.com/spf13/cobra"
+)
+
+var (
+	searchCmd = &cobra.Command{
+		Use:   "search <query>...",
+		Short: "Searches the hooks of the cached catalogs.",
+		Long: `search finds hooks in the cached catalogs. Every term of the query has to
+match the hook's name, path, description, event, header values or body keys
+and values. Matches are ranked by where the terms were found and printed as
+names that can be passed to hook fire. Catalogs are searched at the commits
+locked in hook.lock, and the default catalog is searched in its embedded
+snapshot if it isn't cached.
+
+The search index is kept in the cache directory and is updated for changed
+files on every search and after hook catalog update.`,
+		Example: "hook catalog search stripe invoice.paid",
+		Args:    cobra.MinimumNArgs(1),
+		RunE:    search,
+	}
+	searchJSON bool
+)
+
+func init() {
+	searchCmd.Flags().BoolVar(&searchJSON, "json", false, "print the matches as JSON")
+	catalogCmd.AddCommand(searchCmd)
+}
+
+func search(cmd *cobra.Command, args []string) error {
+	results, err := hook.SearchCatalogs(strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+
+	if searchJSON {
+		if results == nil {
+			results = []*hook.SearchResult{}
+		}
+		b, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(b))
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+	for _, r := range results {
+		name := r.Name
+		if name == "" {
+			name = strings.SplitN(r.Description, "\n", 2)[0]
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", r.Ref, name)
+	}
+	return tw.Flush()
+}
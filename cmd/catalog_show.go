@@ -9,8 +9,15 @@ import (
 
 var (
 	showCmd = &cobra.Command{
-		Use:     "show <url>...",
-		Short:   "Show the catalog config(s).",
+		Use:   "show <url>...",
+		Short: "Show the catalog config(s).",
+		Long: `show prints hooks with the defaults of their catalog's catalog.yaml
+manifest applied. <catalog>@ prints the manifest of the catalog. Hooks can
+be shown at a revision of their catalog with <catalog>@<path>#<revision>.
+
+Hooks and manifests from catalogs start with a comment naming the source they
+were read from: the catalog's cache, a commit locked by hook.lock, a pinned
+revision, or the snapshot of the default catalog embedded in hook.`,
 		Example: "hook catalog show @github/push",
 		RunE:    show,
 	}
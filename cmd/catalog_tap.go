@@ -39,7 +39,14 @@ func defaultName(raw string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	return strings.TrimSuffix(filepath.Base(u.Path), ".git"), nil
+	name := filepath.Base(u.Path)
+	for _, ext := range []string{".git", ".tar.gz", ".tgz", ".zip"} {
+		name = strings.TrimSuffix(name, ext)
+	}
+	if u.Scheme+"://" == hook.OCIScheme {
+		name = strings.SplitN(strings.SplitN(name, "@", 2)[0], ":", 2)[0]
+	}
+	return name, nil
 }
 
 func writeRemoteConfig(m hook.RemoteConfigSet) error {
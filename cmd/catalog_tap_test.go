@@ -28,6 +28,16 @@ func TestDefaultName(t *testing.T) {
 			url:  "https://github.com/eddiezane/hook-catalog.git",
 			want: "hook-catalog",
 		},
+		{
+			name: "archive",
+			url:  "https://example.com/releases/hook-catalog.tar.gz",
+			want: "hook-catalog",
+		},
+		{
+			name: "oci",
+			url:  "oci://ghcr.io/eddiezane/hook-catalog:v1",
+			want: "hook-catalog",
+		},
 	}
 	for _, tc := range testcases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
 	"log"
 
 	"github.com/eddiezane/hook/pkg/hook"
@@ -14,10 +16,14 @@ var (
 		Long:    "update updates a given catalog to the configured revision.",
 		Example: "hook catalog update <name>",
 		RunE:    update,
+
+		SilenceUsage: true,
 	}
+	updateLock bool
 )
 
 func init() {
+	updateCmd.Flags().BoolVar(&updateLock, "lock", false, "Record the updated commits in hook.lock")
 	catalogCmd.AddCommand(updateCmd)
 }
 
@@ -34,6 +40,7 @@ func updateConfig(names ...string) error {
 		}
 	}
 
+	failed := 0
 	for _, n := range names {
 		cfg, err := rc.Get(n)
 		if err != nil {
@@ -44,13 +51,47 @@ func updateConfig(names ...string) error {
 		log.Printf("updating %s@%s", cfg.Name, cfg.Revision)
 
 		if err := cfg.Update(); err != nil {
-			return err
+			log.Print(err)
+			if hint := fetchHint(err); hint != "" {
+				log.Print(hint)
+			}
+			failed++
 		}
 	}
 
+	if _, err = hook.UpdateIndex(); err != nil {
+		if failed == 0 {
+			return err
+		}
+		log.Print(err)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d catalogs failed to update", failed)
+	}
 	return nil
 }
 
+// fetchHint suggests how to fix a failure to fetch a catalog.
+func fetchHint(err error) string {
+	switch {
+	case errors.Is(err, hook.ErrAuth):
+		return "check the credentials for the catalog and git's credential helpers"
+	case errors.Is(err, hook.ErrRefNotFound):
+		return "check the URL and revision of the catalog in the config file"
+	case errors.Is(err, hook.ErrNetwork):
+		return "check your network connection and retry"
+	case errors.Is(err, hook.ErrOffline):
+		return "catalogs can't be updated with --offline"
+	}
+	return ""
+}
+
 func update(cmd *cobra.Command, args []string) error {
-	return updateConfig(args...)
+	if err := updateConfig(args...); err != nil {
+		return err
+	}
+	if updateLock {
+		return lockConfig(args...)
+	}
+	return nil
 }
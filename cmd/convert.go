@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/eddiezane/hook/pkg/hook"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Flags
+	convertTo     string
+	convertOutput string
+
+	convertCommand = &cobra.Command{
+		Use:   "convert <hook>",
+		Short: "Converts a webhook to another representation",
+		Long: `convert rewrites the selected webhook in another representation and writes
+it to stdout, or the path given with --output.
+
+Supported targets are cloudevents-structured, which carries the whole event
+as an application/cloudevents+json body, and cloudevents-binary, which
+carries the event attributes in ce-* headers.`,
+		Example: "hook convert --to cloudevents-structured -o event.yml event.yml",
+		RunE:    convert,
+	}
+)
+
+func init() {
+	convertCommand.Flags().StringVar(&convertTo, "to", "", "Representation to convert to: cloudevents-structured or cloudevents-binary")
+	convertCommand.Flags().StringVarP(&convertOutput, "output", "o", "", "Path to write the converted hooks to. If not specified, hooks are written to stdout")
+	rootCmd.AddCommand(convertCommand)
+}
+
+func convert(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("incorrect number of arguments provided. expected %d", 1)
+	}
+
+	var mode hook.CloudEventsMode
+	switch convertTo {
+	case "cloudevents-structured":
+		mode = hook.CloudEventsStructured
+	case "cloudevents-binary":
+		mode = hook.CloudEventsBinary
+	case "":
+		return errors.New("--to is required")
+	default:
+		return fmt.Errorf("unknown target %q, expected one of: cloudevents-binary, cloudevents-structured", convertTo)
+	}
+
+	hooks, err := hook.NewFromPath(args[0])
+	if err != nil {
+		return err
+	}
+	for i, h := range hooks {
+		if hooks[i], err = hook.ConvertCloudEvents(h, mode); err != nil {
+			return err
+		}
+	}
+	return writeHooks(convertOutput, hooks)
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/eddiezane/hook/pkg/hook"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Flags
+	exportFormat string
+	exportFire   bool
+	exportRedact bool
+
+	exportCommand = &cobra.Command{
+		Use:   "export <hook> <target>",
+		Short: "Exports a webhook as a request for other tools",
+		Long: `export renders the selected webhook exactly as it would be fired at the given
+url and writes it in a format other tools understand, without sending it.
+
+Supported formats are curl, httpie, http (a raw HTTP/1.1 request that can be
+piped into nc), har and postman. With --fire, hooks are sent and the HAR
+includes their responses.
+
+Signed hooks include the signature computed from their secret, which is
+replaced with REDACTED by --redact-signature.
+
+The postman format writes a Postman collection (v2.1) with templates kept as
+Postman variables. If <hook> is a directory, every hook file below it is
+exported, with subdirectories as folders.`,
+		Example: "hook export --format curl @github/push http://localhost:8080",
+		RunE:    export,
+	}
+)
+
+func init() {
+	exportCommand.Flags().StringVarP(&exportFormat, "format", "f", "curl", "Output format: curl, httpie, http, har or postman")
+	exportCommand.Flags().BoolVar(&exportFire, "fire", false, "Fire the hooks and include their responses (har format only)")
+	exportCommand.Flags().BoolVar(&exportRedact, "redact-signature", false, "Replace the signatures of signed hooks with REDACTED")
+	rootCmd.AddCommand(exportCommand)
+}
+
+func export(cmd *cobra.Command, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("incorrect number of arguments provided. expected %d", 2)
+	}
+
+	e, err := hook.GetExporter(exportFormat)
+	if err != nil {
+		return err
+	}
+	if exportFire {
+		if exportFormat != "har" {
+			return errors.New("--fire is only supported for the har format")
+		}
+		if exportRedact {
+			return errors.New("--redact-signature can't be used with --fire")
+		}
+		e = hook.HARExporter{Client: http.DefaultClient}
+	}
+
+	if fi, err := os.Stat(args[0]); err == nil && fi.IsDir() && exportFormat == "postman" {
+		c := hook.NewPostmanCollection(filepath.Base(args[0]))
+		if err := c.AddDir(args[0], args[1]); err != nil {
+			return err
+		}
+		return c.Write(os.Stdout)
+	}
+
+	hooks, err := hook.NewFromPath(args[0])
+	if err != nil {
+		return err
+	}
+	if exportRedact {
+		hook.RedactSignatures(hooks)
+	}
+	return e.Export(os.Stdout, args[1], hooks)
+}
@@ -1,16 +1,44 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
+	"math/rand"
 	"os"
+	"sort"
 
 	"github.com/eddiezane/hook/pkg/hook"
 	"github.com/spf13/cobra"
 )
 
+var (
+	// Flags
+	concurrency int
+	repeat      int
+	rate        string
+	preserve    bool
+	speed       string
+	simulate    string
+	retries     int
+	duplicates  float64
+	shuffle     bool
+	seed        int64
+	dryRun      bool
+)
+
 func init() {
+	fireCommand.Flags().IntVarP(&concurrency, "concurrency", "c", 1, "Number of requests to have in flight at once")
+	fireCommand.Flags().IntVarP(&repeat, "repeat", "n", 1, "Number of times to fire the hooks")
+	fireCommand.Flags().StringVar(&rate, "rate", "", "Maximum rate to start requests at, e.g. 50/s or 100/m")
+	fireCommand.Flags().BoolVar(&preserve, "preserve-timing", false, "Wait the recorded time between hooks before firing each one")
+	fireCommand.Flags().StringVar(&speed, "speed", "1x", "Speed multiplier for --preserve-timing gaps and --simulate retry backoff, e.g. 2x")
+	fireCommand.Flags().StringVar(&simulate, "simulate", "", "Deliver hooks like the given provider would, retrying failures (github, stripe, shopify)")
+	fireCommand.Flags().IntVar(&retries, "retries", -1, "Override the number of retries made by the --simulate policy")
+	fireCommand.Flags().Float64Var(&duplicates, "duplicates", 0, "Probability (0-1) that --simulate delivers an event twice")
+	fireCommand.Flags().BoolVar(&shuffle, "shuffle", false, "Deliver events in a random order with --simulate")
+	fireCommand.Flags().Int64Var(&seed, "seed", 0, "Random seed for --duplicates and --shuffle. If not specified, a random seed is used")
+	fireCommand.Flags().BoolVar(&dryRun, "dry-run", false, "Print the raw HTTP requests that would be sent instead of sending them")
 	rootCmd.AddCommand(fireCommand)
 }
 
@@ -27,24 +55,133 @@ func fire(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("incorrect number of arguments provided. expected %d", 2)
 	}
 
+	if err := checkSimulateFlags(cmd); err != nil {
+		return err
+	}
+
 	path := args[0]
 	hooks, err := hook.NewFromPath(path)
 	if err != nil {
 		return err
 	}
 
-	target := args[1]
-	for _, h := range hooks {
-		res, err := h.Fire(target)
+	if dryRun {
+		return hook.HTTPExporter{}.Export(os.Stdout, args[1], hooks)
+	}
+	if simulate != "" {
+		return fireSimulated(hooks, args[1])
+	}
+
+	pool := &hook.Pool{
+		Concurrency:    concurrency,
+		Repeat:         repeat,
+		PreserveTiming: preserve,
+	}
+	pool.Speed, err = hook.ParseSpeed(speed)
+	if err != nil {
+		return err
+	}
+	if rate != "" {
+		pool.Rate, err = hook.ParseRate(rate)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "error: %v", err)
-			continue
-		}
-		tee := io.TeeReader(res.Body, os.Stdout)
-		if _, err := ioutil.ReadAll(tee); err != nil {
 			return err
 		}
-		defer res.Body.Close()
 	}
+
+	target := args[1]
+	summary := pool.Fire(target, hooks, func(r *hook.Result) {
+		writeResult(os.Stdout, os.Stderr, r)
+	})
+	if concurrency > 1 || repeat > 1 {
+		writeSummary(os.Stderr, summary)
+	}
+	return nil
+}
+
+// simulateConflicts are the flags of the pool that --simulate doesn't support,
+// as simulations deliver each event once in order (plus retries).
+var simulateConflicts = []string{"concurrency", "repeat", "rate", "preserve-timing"}
+
+// checkSimulateFlags returns an error if flags are combined with --simulate
+// that it would ignore, or if flags of --simulate are used without it.
+func checkSimulateFlags(cmd *cobra.Command) error {
+	if simulate == "" {
+		if duplicates > 0 || shuffle {
+			return errors.New("--duplicates and --shuffle require --simulate")
+		}
+		return nil
+	}
+	for _, name := range simulateConflicts {
+		if cmd.Flags().Changed(name) {
+			return fmt.Errorf("--%s can't be used with --simulate", name)
+		}
+	}
+	return nil
+}
+
+// writeResult writes the response body of a fired hook to w, or the error to
+// errw if the request failed.
+func writeResult(w, errw io.Writer, r *hook.Result) {
+	if r.Err != nil {
+		fmt.Fprintf(errw, "error: %v\n", r.Err)
+		return
+	}
+	w.Write(r.Body)
+}
+
+func writeSummary(w io.Writer, s *hook.Summary) {
+	fmt.Fprintf(w, "%d requests in %s, %d errors\n", s.Total, s.Duration, s.Errors)
+	codes := make([]int, 0, len(s.Status))
+	for c := range s.Status {
+		codes = append(codes, c)
+	}
+	sort.Ints(codes)
+	for _, c := range codes {
+		fmt.Fprintf(w, "  %d: %d\n", c, s.Status[c])
+	}
+}
+
+func fireSimulated(hooks []*hook.Hook, target string) error {
+	policy, err := hook.GetDeliveryPolicy(simulate)
+	if err != nil {
+		return err
+	}
+	if retries >= 0 {
+		p := *policy
+		p.Retries = retries
+		policy = &p
+	}
+
+	sim := &hook.Simulator{
+		Policy:     policy,
+		Duplicates: duplicates,
+		Shuffle:    shuffle,
+	}
+	sim.Speed, err = hook.ParseSpeed(speed)
+	if err != nil {
+		return err
+	}
+	if seed != 0 {
+		sim.Rand = rand.New(rand.NewSource(seed))
+	}
+
+	timeline, err := sim.Run(target, hooks, func(d *hook.Delivery) {
+		hook.Timeline{d}.Print(os.Stderr)
+	})
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	events := make(map[int]bool)
+	for _, d := range timeline {
+		events[d.Event] = !d.Failed() || events[d.Event]
+	}
+	for _, ok := range events {
+		if !ok {
+			failed++
+		}
+	}
+	fmt.Fprintf(os.Stderr, "%d deliveries of %d events, %d events never delivered successfully\n", len(timeline), len(events), failed)
 	return nil
 }
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestCheckSimulateFlags(t *testing.T) {
+	defer func() { simulate = "" }()
+
+	for _, tc := range []struct {
+		args    []string
+		wantErr bool
+	}{
+		{args: []string{"--simulate", "github"}},
+		{args: []string{"--simulate", "github", "--speed", "2x", "--retries", "1"}},
+		{args: []string{"--concurrency", "5", "--repeat", "2"}},
+		{args: []string{"--simulate", "github", "--concurrency", "5"}, wantErr: true},
+		{args: []string{"--simulate", "stripe", "-n", "2"}, wantErr: true},
+		{args: []string{"--simulate", "stripe", "--rate", "5/s"}, wantErr: true},
+		{args: []string{"--simulate", "shopify", "--preserve-timing"}, wantErr: true},
+		{args: []string{"--shuffle"}, wantErr: true},
+	} {
+		// Flags can't be unset, so they are reset to their defaults.
+		fireCommand.Flags().VisitAll(func(f *pflag.Flag) {
+			f.Value.Set(f.DefValue)
+			f.Changed = false
+		})
+		if err := fireCommand.ParseFlags(tc.args); err != nil {
+			t.Fatal(err)
+		}
+		if err := checkSimulateFlags(fireCommand); (err != nil) != tc.wantErr {
+			t.Errorf("checkSimulateFlags(%v) = %v, want error: %t", tc.args, err, tc.wantErr)
+		}
+	}
+}
@@ -13,6 +13,10 @@ var rootCmd = &cobra.Command{
 	Short: "Hook is a tool for firing a known collection of webhooks",
 }
 
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&hook.Offline, "offline", false, "don't fetch catalogs, use their caches or the embedded default catalog")
+}
+
 // Execute runs the root command
 func Execute() {
 	hook.Initcfg()
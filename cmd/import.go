@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/eddiezane/hook/pkg/hook"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Flags
+	importOutput string
+
+	importCmd = &cobra.Command{
+		Use:   "import",
+		Short: "Subcommands for importing hooks from other formats",
+	}
+)
+
+func init() {
+	importCmd.PersistentFlags().StringVarP(&importOutput, "output", "o", "", "Path to write the imported hooks to. If not specified, hooks are written to stdout")
+	rootCmd.AddCommand(importCmd)
+}
+
+// writeHooks writes hooks as a multidoc YAML file to path, creating parent
+// directories as needed. If path is empty, the hooks are written to stdout.
+func writeHooks(path string, hooks []*hook.Hook) error {
+	b, err := hook.DumpAll(hooks)
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		_, err := os.Stdout.Write(b)
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0644)
+}
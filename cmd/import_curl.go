@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/eddiezane/hook/pkg/hook"
+	"github.com/spf13/cobra"
+)
+
+var (
+	importCurlCmd = &cobra.Command{
+		Use:   "curl [command]",
+		Short: "Imports a hook from a curl command",
+		Long: `curl converts a curl command line, such as the example deliveries found in
+provider documentation, into a hook.
+
+The command can be given as a single quoted argument, as separate arguments
+after --, or on stdin.`,
+		Example: `hook import curl -o github/ping.yml "curl -X POST -H 'X-GitHub-Event: ping' -d '{\"zen\":\"hi\"}' http://localhost"
+hook import curl -o github/ping.yml -- curl -H 'X-GitHub-Event: ping' -d @ping.json http://localhost
+pbpaste | hook import curl -o github/ping.yml`,
+		RunE: importCurl,
+	}
+)
+
+func init() {
+	importCmd.AddCommand(importCurlCmd)
+}
+
+func importCurl(cmd *cobra.Command, args []string) error {
+	var (
+		h   *hook.Hook
+		err error
+	)
+	switch len(args) {
+	case 0:
+		b, rerr := ioutil.ReadAll(os.Stdin)
+		if rerr != nil {
+			return rerr
+		}
+		h, err = hook.NewFromCurl(string(b))
+	case 1:
+		h, err = hook.NewFromCurl(args[0])
+	default:
+		h, err = hook.NewFromCurlArgs(args)
+	}
+	if err != nil {
+		return err
+	}
+	return writeHooks(importOutput, []*hook.Hook{h})
+}
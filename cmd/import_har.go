@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"regexp"
+
+	"github.com/eddiezane/hook/pkg/hook"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Flags
+	importHARURL    string
+	importHARMethod string
+	importHARBase64 []string
+
+	importHARCmd = &cobra.Command{
+		Use:   "har [file]",
+		Short: "Imports hooks from a HAR archive",
+		Long: `har converts the requests of entries in an HTTP Archive (as produced by
+browser devtools and many proxies) into hooks. The archive is read from the
+given file, or stdin if no file is given.`,
+		Example: "hook import har --url 'example.com/webhooks' --method POST -o webhooks.yml capture.har",
+		RunE:    importHAR,
+	}
+)
+
+func init() {
+	importHARCmd.Flags().StringVar(&importHARURL, "url", "", "Only import entries with a URL matching this regular expression")
+	importHARCmd.Flags().StringVar(&importHARMethod, "method", "", "Only import entries with this HTTP method")
+	importHARCmd.Flags().StringArrayVar(&importHARBase64, "base64", nil, "comma separated list of fields to base64 decode")
+	importCmd.AddCommand(importHARCmd)
+}
+
+func importHAR(cmd *cobra.Command, args []string) error {
+	filter := hook.HARFilter{Method: importHARMethod}
+	if importHARURL != "" {
+		re, err := regexp.Compile(importHARURL)
+		if err != nil {
+			return err
+		}
+		filter.URL = re
+	}
+
+	var r io.Reader = os.Stdin
+	if len(args) > 0 {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	hooks, err := hook.NewFromHAR(r, filter, decodeOptions(importHARBase64)...)
+	if err != nil {
+		return err
+	}
+	return writeHooks(importOutput, hooks)
+}
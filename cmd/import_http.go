@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"io"
+	"os"
+
+	"github.com/eddiezane/hook/pkg/hook"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Flags
+	importHTTPBase64      []string
+	importHTTPCloudEvents bool
+	importHTTPUnwrap      bool
+
+	importHTTPCmd = &cobra.Command{
+		Use:   "http [file]...",
+		Short: "Imports hooks from raw HTTP requests",
+		Long: `http converts one or more raw HTTP/1.1 request messages, such as those
+captured by nc -l or a tcpdump follow-stream, into hooks. Requests are read
+from the given files, or stdin if no files are given.`,
+		Example: "nc -l 8080 | hook import http -o webhook.yml",
+		RunE:    importHTTP,
+	}
+)
+
+func init() {
+	importHTTPCmd.Flags().StringArrayVar(&importHTTPBase64, "base64", nil, "comma separated list of fields to base64 decode")
+	importHTTPCmd.Flags().BoolVar(&importHTTPCloudEvents, "cloudevents", false, "Detect CloudEvents and regenerate their id and time when fired")
+	importHTTPCmd.Flags().BoolVar(&importHTTPUnwrap, "unwrap", false, "Unwrap SNS, Pub/Sub push and EventBridge envelopes")
+	importCmd.AddCommand(importHTTPCmd)
+}
+
+func importHTTP(cmd *cobra.Command, args []string) error {
+	opts := captureOptions(importHTTPBase64, importHTTPCloudEvents, importHTTPUnwrap)
+
+	var readers []io.Reader
+	for _, path := range args {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		readers = append(readers, f)
+	}
+	if len(readers) == 0 {
+		readers = append(readers, os.Stdin)
+	}
+
+	var hooks []*hook.Hook
+	for _, r := range readers {
+		h, err := hook.NewFromHTTP(r, opts...)
+		if err != nil {
+			return err
+		}
+		hooks = append(hooks, h...)
+	}
+	return writeHooks(importOutput, hooks)
+}
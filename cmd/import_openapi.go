@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/eddiezane/hook/pkg/hook"
+	"github.com/spf13/cobra"
+)
+
+var (
+	importOpenAPICmd = &cobra.Command{
+		Use:   "openapi <spec>",
+		Short: "Generates hooks from OpenAPI webhook definitions",
+		Long: `openapi walks the webhooks section of an OpenAPI 3.1 document (YAML or JSON)
+and generates a catalog directory with one hook file per webhook operation.
+
+Examples embedded in the document are used as hook bodies. If an operation
+has no examples, a body is synthesized from its schema. Header and query
+parameters are included using their examples.`,
+		Example: "hook import openapi -o catalog/github api.github.com.yaml",
+		RunE:    importOpenAPI,
+	}
+)
+
+func init() {
+	importCmd.AddCommand(importOpenAPICmd)
+}
+
+func importOpenAPI(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return errors.New("expected a single OpenAPI document")
+	}
+	if importOutput == "" {
+		return errors.New("--output directory is required")
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	files, err := hook.NewFromOpenAPI(f)
+	if err != nil {
+		return err
+	}
+	for _, g := range files {
+		path := filepath.Join(importOutput, g.Path)
+		if err := writeHooks(path, g.Hooks); err != nil {
+			return err
+		}
+	}
+	log.Printf("wrote %d hook files to %s", len(files), importOutput)
+	return nil
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/eddiezane/hook/pkg/hook"
+	"github.com/spf13/cobra"
+)
+
+var (
+	importPostmanCmd = &cobra.Command{
+		Use:   "postman <collection>",
+		Short: "Generates hooks from a Postman collection",
+		Long: `postman converts the requests of a Postman collection (v2.1) into a catalog
+directory with one hook file per request. Folders become directories.
+
+Collection variables become hook variables and {{variable}} references are
+rewritten as templates, so they are filled in when the hook is fired. The
+dynamic variables {{$guid}}, {{$timestamp}} and {{$randomInt}} are mapped to
+the uuid, unix and randInt template functions.`,
+		Example: "hook import postman -o catalog/stripe stripe.postman_collection.json",
+		RunE:    importPostman,
+	}
+)
+
+func init() {
+	importCmd.AddCommand(importPostmanCmd)
+}
+
+func importPostman(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return errors.New("expected a single Postman collection")
+	}
+	if importOutput == "" {
+		return errors.New("--output directory is required")
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	files, err := hook.NewFromPostman(f)
+	if err != nil {
+		return err
+	}
+	for _, g := range files {
+		path := filepath.Join(importOutput, g.Path)
+		if err := writeHooks(path, g.Hooks); err != nil {
+			return err
+		}
+	}
+	log.Printf("wrote %d hook files to %s", len(files), importOutput)
+	return nil
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/eddiezane/hook/pkg/hook"
+	"github.com/spf13/cobra"
+)
+
+var (
+	lintCommand = &cobra.Command{
+		Use:   "lint <path>...",
+		Short: "Validates webhooks",
+		Long: `lint validates every document of the given hook files. Directories are
+walked for .yaml, .yml and .hook files, and <catalog>@[path] lints the files
+of a catalog.
+
+Documents are checked for unknown fields, unknown methods, invalid JSON
+bodies, transform paths missing from the body, unresolvable template
+variables, incomplete signing settings and values that look like secrets. The
+catalog.yaml manifest of a linted directory is checked too. Problems are
+printed as file:line diagnostics and the command exits non-zero if there
+are any.`,
+		Example:      "hook lint catalog/ github@",
+		RunE:         lint,
+		SilenceUsage: true,
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(lintCommand)
+}
+
+func lint(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("incorrect number of arguments provided. expected at least %d", 1)
+	}
+
+	n := 0
+	for _, path := range args {
+		diags, err := hook.LintPath(path)
+		if err != nil {
+			return err
+		}
+		for _, d := range diags {
+			fmt.Fprintln(cmd.OutOrStdout(), d)
+		}
+		n += len(diags)
+	}
+	if n > 0 {
+		return fmt.Errorf("%d problems found", n)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/eddiezane/hook/pkg/hook"
+	"github.com/spf13/cobra"
+)
+
+var (
+	migrateCommand = &cobra.Command{
+		Use:   "migrate <path>...",
+		Short: "Rewrites webhooks to the current document version",
+		Long: `migrate rewrites hook files in place as ` + hook.APIVersion + ` documents. Directories are
+walked for .yaml, .yml and .hook files. Files that are already current are
+left untouched.
+
+Comments and formatting are not preserved in migrated files.`,
+		Example: "hook migrate catalog/",
+		RunE:    migrate,
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(migrateCommand)
+}
+
+func migrate(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("incorrect number of arguments provided. expected at least %d", 1)
+	}
+
+	for _, arg := range args {
+		err := filepath.Walk(arg, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			// Explicit arguments are migrated regardless of their extension.
+			if info.IsDir() || (path != arg && !hook.IsHookFile(path)) {
+				return nil
+			}
+			return migrateFile(path, info.Mode())
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func migrateFile(path string, mode os.FileMode) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	out, changed, err := hook.Migrate(bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	if !changed {
+		return nil
+	}
+	if err := ioutil.WriteFile(path, out, mode); err != nil {
+		return err
+	}
+	log.Printf("migrated %s", path)
+	return nil
+}
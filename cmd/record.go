@@ -2,11 +2,13 @@ package cmd
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"sync"
+	"time"
 
 	"github.com/eddiezane/hook/pkg/hook"
 
@@ -15,8 +17,10 @@ import (
 
 var (
 	// Flags
-	port   string
-	base64 []string
+	port        string
+	base64      []string
+	cloudevents bool
+	unwrap      bool
 
 	recordCommand = &cobra.Command{
 		Use:     "record",
@@ -32,6 +36,11 @@ type recorder struct {
 	f  *os.File
 
 	opts []hook.Option
+	// confirm enables confirming SNS subscriptions, which aren't recorded.
+	confirm bool
+	client  *http.Client
+	// now returns the time a request was received. Overridable for tests.
+	now func() time.Time
 }
 
 func newRecorder(path string, opts ...hook.Option) (*recorder, error) {
@@ -41,8 +50,10 @@ func newRecorder(path string, opts ...hook.Option) (*recorder, error) {
 	}
 
 	return &recorder{
-		f:    f,
-		opts: opts,
+		f:      f,
+		opts:   opts,
+		client: http.DefaultClient,
+		now:    time.Now,
 	}, nil
 }
 
@@ -61,6 +72,20 @@ func (r *recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		log.Fatal(err)
 	}
 
+	if r.confirm {
+		if u, ok := hook.SNSSubscribeURL(h); ok {
+			if err := hook.ConfirmSNSSubscription(r.client, u); err != nil {
+				log.Println("error confirming SNS subscription:", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			log.Println("confirmed SNS subscription:", u)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+	}
+	h.ReceivedAt = r.now().UTC()
+
 	s, err := h.Dump()
 	if err != nil {
 		log.Println("error dumping hook:", err, h)
@@ -74,12 +99,14 @@ func (r *recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		defer r.mu.Unlock()
 		fw := bufio.NewWriter(r.f)
 		if fi, err := r.f.Stat(); err == nil && fi.Size() > 0 {
-			// If file has data in it already, append doc separator.
+			// If file has data in it already, append doc separator. The
+			// file already starts with the schema modeline.
 			if _, err := fw.Write([]byte("---\n")); err != nil {
 				log.Println("error writing doc separator:", err)
 				w.WriteHeader(http.StatusInternalServerError)
 				return
 			}
+			s = bytes.TrimPrefix(s, []byte(hook.SchemaModeline))
 		}
 		if _, err := fw.Write(s); err != nil {
 			log.Println("error writing file:", err)
@@ -92,21 +119,40 @@ func (r *recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
-func record(cmd *cobra.Command, args []string) error {
-	if len(args) != 1 {
-		return fmt.Errorf("incorrect number of arguments provided. expected %d", 1)
-	}
-
+// decodeOptions returns the options to base64 decode the given fields of new
+// hooks.
+func decodeOptions(base64 []string) []hook.Option {
 	var opts []hook.Option
 	b64t := &hook.Base64Transformer{}
 	for _, f := range base64 {
 		opts = append(opts, hook.DecodeOption(b64t, f))
 	}
+	return opts
+}
+
+// captureOptions returns the options for hooks captured from requests, which
+// are shared by record and import http.
+func captureOptions(base64 []string, cloudevents, unwrap bool) []hook.Option {
+	opts := decodeOptions(base64)
+	if cloudevents {
+		opts = append(opts, hook.CloudEventsOption())
+	}
+	if unwrap {
+		opts = append(opts, hook.UnwrapOption())
+	}
+	return opts
+}
+
+func record(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("incorrect number of arguments provided. expected %d", 1)
+	}
 
-	r, err := newRecorder(args[0], opts...)
+	r, err := newRecorder(args[0], captureOptions(base64, cloudevents, unwrap)...)
 	if err != nil {
 		return err
 	}
+	r.confirm = unwrap
 	defer r.close()
 
 	log.Printf("starting server on port %s", port)
@@ -116,5 +162,7 @@ func record(cmd *cobra.Command, args []string) error {
 func init() {
 	recordCommand.Flags().StringVar(&port, "port", "8080", "Port to listen on")
 	recordCommand.Flags().StringArrayVar(&base64, "base64", nil, "comma separated list of fields to base64 decode")
+	recordCommand.Flags().BoolVar(&cloudevents, "cloudevents", false, "Detect CloudEvents and regenerate their id and time when fired")
+	recordCommand.Flags().BoolVar(&unwrap, "unwrap", false, "Unwrap SNS, Pub/Sub push and EventBridge envelopes and confirm SNS subscriptions")
 	rootCmd.AddCommand(recordCommand)
 }
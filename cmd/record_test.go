@@ -1,13 +1,16 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/eddiezane/hook/pkg/hook"
 	"github.com/kylelemons/godebug/diff"
@@ -66,12 +69,15 @@ func TestRecord(t *testing.T) {
 	}{
 		{
 			name: "empty body and params",
-			want: `method: GET
+			want: `apiVersion: hook/v1
+kind: Hook
+method: GET
 headers:
   Accept-Encoding:
   - gzip
   User-Agent:
   - Go-http-client/1.1
+receivedAt: 2019-12-25T08:30:00Z
 `,
 			method: http.MethodGet,
 			body:   "",
@@ -79,7 +85,9 @@ headers:
 		},
 		{
 			name: "body and params with headers",
-			want: `method: POST
+			want: `apiVersion: hook/v1
+kind: Hook
+method: POST
 headers:
   Accept-Encoding:
   - gzip
@@ -96,6 +104,7 @@ params:
   other:
   - one
   - two
+receivedAt: 2019-12-25T08:30:00Z
 `,
 			method:  http.MethodPost,
 			headers: http.Header{"Captain": {"Hook"}},
@@ -104,7 +113,9 @@ params:
 		},
 		{
 			name: "json body",
-			want: `method: POST
+			want: `apiVersion: hook/v1
+kind: Hook
+method: POST
 headers:
   Accept-Encoding:
   - gzip
@@ -118,6 +129,7 @@ body: |-
   {
     "foo": "bar"
   }
+receivedAt: 2019-12-25T08:30:00Z
 `,
 			method:  http.MethodPost,
 			headers: http.Header{"Content-Type": []string{"application/json"}},
@@ -125,7 +137,9 @@ body: |-
 		},
 		{
 			name: "base64 decode field",
-			want: `method: POST
+			want: `apiVersion: hook/v1
+kind: Hook
+method: POST
 headers:
   Accept-Encoding:
   - gzip
@@ -142,6 +156,7 @@ body: |-
 transform:
   base64:
   - foo
+receivedAt: 2019-12-25T08:30:00Z
 `,
 			method:  http.MethodPost,
 			headers: http.Header{"Content-Type": []string{"application/json"}},
@@ -150,7 +165,9 @@ transform:
 		},
 		{
 			name: "base64 decode struct",
-			want: `method: POST
+			want: `apiVersion: hook/v1
+kind: Hook
+method: POST
 headers:
   Accept-Encoding:
   - gzip
@@ -169,12 +186,41 @@ body: |-
 transform:
   base64:
   - foo
+receivedAt: 2019-12-25T08:30:00Z
 `,
 			method:  http.MethodPost,
 			headers: http.Header{"Content-Type": []string{"application/json"}},
 			body:    `{"foo": "eyJiYXIiOiAiYmF6In0="}`,
 			opts:    []hook.Option{hook.DecodeOption(hook.Base64Transformer{}, "foo")},
 		},
+		{
+			name: "unwrap pubsub push",
+			want: `apiVersion: hook/v1
+kind: Hook
+method: POST
+headers:
+  Accept-Encoding:
+  - gzip
+  Content-Type:
+  - application/json
+  User-Agent:
+  - Go-http-client/1.1
+body: |-
+  {
+    "foo": "bar"
+  }
+envelope:
+  type: pubsub-push
+  attributes:
+    k: v
+    subscription: projects/p/subscriptions/s
+receivedAt: 2019-12-25T08:30:00Z
+`,
+			method:  http.MethodPost,
+			headers: http.Header{"Content-Type": []string{"application/json"}},
+			body:    `{"message":{"data":"eyJmb28iOiJiYXIifQ==","attributes":{"k":"v"},"messageId":"1"},"subscription":"projects/p/subscriptions/s"}`,
+			opts:    []hook.Option{hook.UnwrapOption()},
+		},
 	}
 
 	for _, tc := range testcases {
@@ -187,6 +233,9 @@ transform:
 				t.Fatal(err)
 			}
 			defer r.close()
+			r.now = func() time.Time {
+				return time.Date(2019, 12, 25, 8, 30, 0, 0, time.UTC)
+			}
 
 			srv := httptest.NewServer(r)
 			defer srv.Close()
@@ -204,7 +253,7 @@ transform:
 				t.Fatal(err)
 			}
 			got := readfile(t, f)
-			if d := diff.Diff(tc.want, got); d != "" {
+			if d := diff.Diff(hook.SchemaModeline+tc.want, got); d != "" {
 				t.Error(d)
 			}
 
@@ -212,7 +261,7 @@ transform:
 			if _, err := client.Do(req); err != nil {
 				t.Fatal(err)
 			}
-			want := fmt.Sprintf("%s---\n%s", tc.want, tc.want)
+			want := fmt.Sprintf("%s%s---\n%s", hook.SchemaModeline, tc.want, tc.want)
 			got = readfile(t, f)
 			if d := diff.Diff(want, got); d != "" {
 				t.Error(d)
@@ -220,3 +269,73 @@ transform:
 		})
 	}
 }
+
+func TestRecordSNSSubscription(t *testing.T) {
+	confirmed := make(chan string, 1)
+	sns := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		confirmed <- r.Host
+	}))
+	defer sns.Close()
+
+	f := testfile(t, "hook.yml")
+	defer deletefile(t, f)
+
+	r, err := newRecorder(f.Name(), hook.UnwrapOption())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.close()
+	r.confirm = true
+	// Subscriptions are only confirmed at SNS endpoints, which are redirected
+	// to the test server.
+	client := sns.Client()
+	transport := client.Transport.(*http.Transport)
+	transport.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, network, sns.Listener.Addr().String())
+	}
+	transport.TLSClientConfig.InsecureSkipVerify = true
+	r.client = client
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	confirm := func(subscribeURL string) int {
+		body := fmt.Sprintf(`{"Type":"SubscriptionConfirmation","TopicArn":"arn:aws:sns:us-east-1:1:t","SubscribeURL":%q}`, subscribeURL)
+		req, err := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("X-Amz-Sns-Message-Type", "SubscriptionConfirmation")
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		return res.StatusCode
+	}
+
+	if got := confirm("https://sns.us-east-1.amazonaws.com/?Action=ConfirmSubscription"); got != http.StatusOK {
+		t.Errorf("got status %d", got)
+	}
+	select {
+	case host := <-confirmed:
+		if host != "sns.us-east-1.amazonaws.com" {
+			t.Errorf("confirmed at %s", host)
+		}
+	default:
+		t.Error("subscription was not confirmed")
+	}
+
+	// Other URLs aren't visited.
+	if got := confirm(sns.URL + "/confirm"); got == http.StatusOK {
+		t.Errorf("got status %d for a non-SNS SubscribeURL", got)
+	}
+	select {
+	case host := <-confirmed:
+		t.Errorf("confirmed at %s", host)
+	default:
+	}
+	if got := readfile(t, f); got != "" {
+		t.Errorf("confirmation was recorded: %s", got)
+	}
+}
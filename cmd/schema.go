@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"github.com/eddiezane/hook/pkg/hook"
+	"github.com/spf13/cobra"
+)
+
+var (
+	schemaCommand = &cobra.Command{
+		Use:   "schema",
+		Short: "Prints the JSON Schema of hook files",
+		Long: `schema prints the JSON Schema of ` + hook.APIVersion + ` hook documents, for editors and
+other tools that validate YAML.
+
+Files written by hook start with a yaml-language-server modeline pointing at
+the published schema, so editors using it pick up the schema automatically.`,
+		Example: "hook schema > hook.schema.json",
+		RunE:    schema,
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(schemaCommand)
+}
+
+func schema(cmd *cobra.Command, args []string) error {
+	b, err := hook.Schema()
+	if err != nil {
+		return err
+	}
+	_, err = cmd.OutOrStdout().Write(b)
+	return err
+}
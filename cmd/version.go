@@ -2,11 +2,11 @@ package cmd
 
 import (
 	"fmt"
+
+	"github.com/eddiezane/hook/pkg/hook"
 	"github.com/spf13/cobra"
 )
 
-var version = "0.0.1"
-
 func init() {
 	rootCmd.AddCommand(versionCommand)
 }
@@ -15,6 +15,6 @@ var versionCommand = &cobra.Command{
 	Use:   "version",
 	Short: "Prints out the version of hook",
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println(fmt.Sprintf("Hook version %s", version))
+		fmt.Println(fmt.Sprintf("Hook version %s", hook.Version))
 	},
 }
@@ -3,11 +3,13 @@ module github.com/eddiezane/hook
 go 1.13
 
 require (
+	github.com/go-git/go-git/v5 v5.1.0
 	github.com/google/go-cmp v0.3.1
 	github.com/kylelemons/godebug v1.1.0
 	github.com/spf13/cobra v0.0.5
+	github.com/spf13/pflag v1.0.3
 	github.com/spf13/viper v1.3.2
 	github.com/tidwall/gjson v1.3.5
 	github.com/tidwall/sjson v1.0.4
-	gopkg.in/yaml.v2 v2.2.2
+	gopkg.in/yaml.v2 v2.2.4
 )
@@ -0,0 +1,146 @@
+package hook
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BenchReport aggregates latency and error statistics for a load test.
+type BenchReport struct {
+	// Classes counts responses by status class ("2xx", "5xx", ...). Requests
+	// that failed, including ones whose response body couldn't be read, are
+	// counted under "error".
+	Classes   map[string]int
+	Latencies []time.Duration
+	Duration  time.Duration
+
+	sorted bool
+}
+
+// NewBenchReport returns an empty BenchReport.
+func NewBenchReport() *BenchReport {
+	return &BenchReport{Classes: make(map[string]int)}
+}
+
+// Add records a single result in the report.
+func (b *BenchReport) Add(r *Result) {
+	b.Latencies = append(b.Latencies, r.Duration)
+	b.sorted = false
+	if r.Err != nil || r.Response == nil {
+		b.Classes["error"]++
+		return
+	}
+	b.Classes[fmt.Sprintf("%dxx", r.Response.StatusCode/100)]++
+}
+
+// Requests returns the number of requests recorded.
+func (b *BenchReport) Requests() int {
+	return len(b.Latencies)
+}
+
+// Throughput returns the number of requests completed per second.
+func (b *BenchReport) Throughput() float64 {
+	if b.Duration <= 0 {
+		return 0
+	}
+	return float64(b.Requests()) / b.Duration.Seconds()
+}
+
+func (b *BenchReport) sort() {
+	if b.sorted {
+		return
+	}
+	sort.Slice(b.Latencies, func(i, j int) bool {
+		return b.Latencies[i] < b.Latencies[j]
+	})
+	b.sorted = true
+}
+
+// Percentile returns the latency at the given percentile (0-100) using the
+// nearest-rank method.
+func (b *BenchReport) Percentile(p float64) time.Duration {
+	if len(b.Latencies) == 0 {
+		return 0
+	}
+	b.sort()
+	rank := int(math.Ceil(p / 100 * float64(len(b.Latencies))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(b.Latencies) {
+		rank = len(b.Latencies)
+	}
+	return b.Latencies[rank-1]
+}
+
+// histogramBuckets are the upper bounds of the buckets printed by Print.
+var histogramBuckets = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	5 * time.Second,
+}
+
+// Histogram returns the number of requests falling into each of the
+// histogramBuckets. The final element counts requests slower than the last
+// bucket.
+func (b *BenchReport) Histogram() []int {
+	counts := make([]int, len(histogramBuckets)+1)
+	for _, l := range b.Latencies {
+		i := sort.Search(len(histogramBuckets), func(i int) bool {
+			return l <= histogramBuckets[i]
+		})
+		counts[i]++
+	}
+	return counts
+}
+
+// Print writes a human readable version of the report to w.
+func (b *BenchReport) Print(w io.Writer) {
+	fmt.Fprintf(w, "requests:   %d in %s\n", b.Requests(), b.Duration.Round(time.Millisecond))
+	fmt.Fprintf(w, "throughput: %.2f req/s\n", b.Throughput())
+	if b.Requests() == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "latency:    p50 %s, p90 %s, p99 %s, max %s\n",
+		b.Percentile(50), b.Percentile(90), b.Percentile(99), b.Percentile(100))
+
+	classes := make([]string, 0, len(b.Classes))
+	for c := range b.Classes {
+		classes = append(classes, c)
+	}
+	sort.Strings(classes)
+	fmt.Fprintln(w, "status:")
+	for _, c := range classes {
+		n := b.Classes[c]
+		fmt.Fprintf(w, "  %-5s %d (%.1f%%)\n", c, n, 100*float64(n)/float64(b.Requests()))
+	}
+
+	fmt.Fprintln(w, "histogram:")
+	counts := b.Histogram()
+	max := 0
+	for _, n := range counts {
+		if n > max {
+			max = n
+		}
+	}
+	for i, n := range counts {
+		label := fmt.Sprintf(" > %s", histogramBuckets[len(histogramBuckets)-1])
+		if i < len(histogramBuckets) {
+			label = fmt.Sprintf("<= %s", histogramBuckets[i])
+		}
+		bar := strings.Repeat("#", 40*n/max)
+		fmt.Fprintf(w, "  %-8s %6d %s\n", label, n, bar)
+	}
+}
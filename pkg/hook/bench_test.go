@@ -0,0 +1,95 @@
+package hook
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBenchReport(t *testing.T) {
+	b := NewBenchReport()
+	for i := 1; i <= 100; i++ {
+		r := &Result{
+			Duration: time.Duration(i) * time.Millisecond,
+			Response: &http.Response{StatusCode: http.StatusOK},
+		}
+		switch {
+		case i%10 == 0:
+			r.Response.StatusCode = http.StatusInternalServerError
+		case i%25 == 1:
+			r.Response = nil
+			r.Err = errors.New("connection refused")
+		case i%25 == 2:
+			// The response body failed to be read.
+			r.Err = errors.New("unexpected EOF")
+		}
+		b.Add(r)
+	}
+	b.Duration = 2 * time.Second
+
+	for p, want := range map[float64]time.Duration{
+		50:  50 * time.Millisecond,
+		90:  90 * time.Millisecond,
+		99:  99 * time.Millisecond,
+		100: 100 * time.Millisecond,
+	} {
+		if got := b.Percentile(p); got != want {
+			t.Errorf("Percentile(%v): got %s, want %s", p, got, want)
+		}
+	}
+
+	wantClasses := map[string]int{"2xx": 82, "5xx": 10, "error": 8}
+	if diff := cmp.Diff(wantClasses, b.Classes); diff != "" {
+		t.Error(diff)
+	}
+
+	if got := b.Throughput(); got != 50 {
+		t.Errorf("Throughput: got %v, want 50", got)
+	}
+
+	buf := new(bytes.Buffer)
+	b.Print(buf)
+	if !strings.Contains(buf.String(), "p50 50ms, p90 90ms, p99 99ms") {
+		t.Errorf("Print: missing percentiles in output:\n%s", buf)
+	}
+}
+
+func TestBenchDuration(t *testing.T) {
+	s := &concurrencyServer{}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	h := &Hook{
+		Method:   http.MethodPost,
+		Body:     `{"id":"{{ uuid }}"}`,
+		Template: true,
+	}
+	p := &Pool{
+		Client:      srv.Client(),
+		Concurrency: 2,
+		Duration:    50 * time.Millisecond,
+	}
+	b := NewBenchReport()
+	p.Fire(srv.URL, []*Hook{h}, b.Add)
+
+	if b.Requests() == 0 {
+		t.Fatal("no requests were made")
+	}
+	if b.Classes["2xx"] != b.Requests() {
+		t.Errorf("got classes %v, want all 2xx", b.Classes)
+	}
+
+	seen := map[string]bool{}
+	for _, body := range s.bodies {
+		if seen[body] {
+			t.Errorf("duplicate body %s", body)
+		}
+		seen[body] = true
+	}
+}
@@ -2,8 +2,10 @@ package hook
 
 import (
 	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 
@@ -11,30 +13,122 @@ import (
 )
 
 // ParsePath takes a hook name of the form <catalog>@<path> and returns the
-// individual pieces.
+// individual pieces. Revisions are dropped, see ParseRef.
 func ParsePath(uri string) (catalog string, path string) {
+	catalog, path, _, _ = ParseRef(uri)
+	return catalog, path
+}
+
+// ParseRef takes a hook name of the form <catalog>@<path>, optionally pinned
+// to a revision of the catalog as <catalog>@<path>#<revision> or
+// <catalog>:<revision>@<path>, and returns the individual pieces. The default
+// catalog is written as @<path> or :<revision>@<path>.
+func ParseRef(uri string) (catalog, path, revision string, err error) {
 	s := strings.SplitN(uri, "@", 2)
 	if len(s) == 1 {
-		return "", s[0]
+		return "", s[0], "", nil
 	}
+	catalog, path = s[0], s[1]
 
-	if s[0] == "" && strings.Contains(uri, "@") {
-		return "@", s[1]
+	if i := strings.Index(catalog, ":"); i >= 0 {
+		catalog, revision = catalog[:i], catalog[i+1:]
+		if revision == "" {
+			return "", "", "", fmt.Errorf("%s: empty revision", uri)
+		}
 	}
-
-	return s[0], s[1]
+	if i := strings.LastIndex(path, "#"); i >= 0 {
+		if revision != "" {
+			return "", "", "", fmt.Errorf("%s: revision given twice", uri)
+		}
+		path, revision = path[:i], path[i+1:]
+		if revision == "" {
+			return "", "", "", fmt.Errorf("%s: empty revision", uri)
+		}
+	}
+	if catalog == "" {
+		catalog = DefaultCatalog.Name
+	}
+	return catalog, path, revision, nil
 }
 
-// Catalog represents a mechanism for fetching hook configurations.
+// Catalog represents a mechanism for fetching hook configurations. Paths are
+// slash separated and relative to the root of the catalog, except for
+// LocalCatalog which accepts any local path.
 type Catalog interface {
-	Open(path string) (*os.File, error)
+	// Open opens the file at path for reading.
+	Open(path string) (io.ReadCloser, error)
+	// Stat returns information about the file or directory at path.
+	Stat(path string) (os.FileInfo, error)
+	// ReadDir returns the entries of the directory at path, sorted by name.
+	ReadDir(path string) ([]os.FileInfo, error)
+}
+
+// GetCatalog returns the catalog and the path within it for a hook name of
+// the form <catalog>@<path>. Names without a catalog refer to local paths.
+// Names pinned to a revision are read at that revision, and other catalogs
+// locked by the project's lockfile at the locked commit. The default catalog
+// is read from its embedded snapshot if it isn't cached and can't be cloned.
+func GetCatalog(uri string) (Catalog, string, error) {
+	c, path, _, err := resolveCatalog(uri)
+	return c, path, err
+}
+
+// resolveCatalog is like GetCatalog, but also returns where the hooks of a
+// remote catalog are read from. The source is nil for local paths.
+func resolveCatalog(uri string) (Catalog, string, *catalogSource, error) {
+	catalog, path, revision, err := ParseRef(uri)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if catalog == "" {
+		return LocalCatalog{}, path, nil, nil
+	}
+	rc, err := GetRemoteConfig(catalog)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if revision != "" {
+		c, err := rc.AtRevision(revision)
+		return c, path, &catalogSource{rc: rc, rev: revision, from: "pinned"}, err
+	}
+	lock, _, err := LoadLockfile()
+	if err != nil {
+		return nil, "", nil, err
+	}
+	c, src, err := lock.remoteCatalog(rc)
+	return c, path, src, err
+}
+
+// catalogSource describes where the hooks of a remote catalog are read from.
+type catalogSource struct {
+	rc *RemoteConfig
+	// rev is the revision the catalog is read at, or "" for what is checked
+	// out in its cache.
+	rev string
+	// from is the kind of source, such as cache or embedded snapshot.
+	from string
+}
+
+func (s *catalogSource) String() string {
+	rev := s.rev
+	if rev == "" && s.from == "cache" {
+		// Only looked up when shown, as it may have to run git.
+		rev, _ = s.rc.Commit()
+	}
+	if rev == "" {
+		return fmt.Sprintf("%s %s (%s)", s.rc.Name, s.rc.URL, s.from)
+	}
+	return fmt.Sprintf("%s %s (%s at %s)", s.rc.Name, s.rc.URL, s.from, rev)
 }
 
-// RemoteConfig describes a single catalog remote.
+// RemoteConfig describes a single catalog remote. URLs of .tar.gz and .zip
+// archives are downloaded instead of cloned, see ArchiveFetcher.
 type RemoteConfig struct {
 	Name     string
 	URL      string
 	Revision string `yaml:",omitempty"`
+	// Checksum is the expected digest of archives, as sha256:<hex>.
+	Checksum string `yaml:",omitempty"`
 }
 
 // Path returns the cache path where the remote cache exists.
@@ -47,29 +141,55 @@ func (rc *RemoteConfig) isCached() bool {
 	return !os.IsNotExist(err)
 }
 
-// Open returns the file corresponding to the file in the remote config,
-// cloning the config locally if it has not occured yet.
-func (rc *RemoteConfig) Open(path string) (*os.File, error) {
+// dir returns the local checkout of the catalog, cloning it if that has not
+// occured yet.
+func (rc *RemoteConfig) dir() (DirCatalog, error) {
 	if !rc.isCached() {
 		if err := rc.Clone(); err != nil {
-			return nil, err
+			return "", err
 		}
 	}
+	return DirCatalog(rc.Path()), nil
+}
 
-	return openFile(filepath.Join(rc.Path(), path))
+// Open opens the file at path in the remote catalog.
+func (rc *RemoteConfig) Open(path string) (io.ReadCloser, error) {
+	d, err := rc.dir()
+	if err != nil {
+		return nil, err
+	}
+	return d.Open(path)
 }
 
-var newCommand func(name, command string, args ...string) runnable = execCommand
+// Stat returns information about the file at path in the remote catalog.
+func (rc *RemoteConfig) Stat(path string) (os.FileInfo, error) {
+	d, err := rc.dir()
+	if err != nil {
+		return nil, err
+	}
+	return d.Stat(path)
+}
 
-type runnable interface {
-	Run() error
+// ReadDir returns the entries of the directory at path in the remote
+// catalog.
+func (rc *RemoteConfig) ReadDir(path string) ([]os.FileInfo, error) {
+	d, err := rc.dir()
+	if err != nil {
+		return nil, err
+	}
+	return d.ReadDir(path)
 }
 
-func execCommand(_, command string, args ...string) runnable {
-	cmd := exec.Command(command, args...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd
+// Commit returns the commit checked out in the cache of the catalog.
+func (rc *RemoteConfig) Commit() (string, error) {
+	if _, err := rc.dir(); err != nil {
+		return "", err
+	}
+	f, err := rc.fetcher()
+	if err != nil {
+		return "", err
+	}
+	return f.Resolve(rc, "HEAD")
 }
 
 // Clone clones the remote catalog to the hook cache.
@@ -77,15 +197,11 @@ func (rc *RemoteConfig) Clone() error {
 	if rc.Name == "" {
 		return errors.New("RemoteConfig must have a name")
 	}
-
-	args := []string{"clone"}
-	if rc.Revision != "" {
-		args = append(args, "-b", rc.Revision)
+	f, err := rc.fetcher()
+	if err != nil {
+		return err
 	}
-	args = append(args, rc.URL, rc.Path())
-
-	cmd := newCommand(rc.Name, "git", args...)
-	return cmd.Run()
+	return f.Clone(rc)
 }
 
 // Update pulls the latest version of the catalog to the hook cache.
@@ -99,44 +215,149 @@ func (rc *RemoteConfig) Update() error {
 		return rc.Clone()
 	}
 
-	gitdir := filepath.Join(rc.Path(), ".git")
-	fetch := newCommand(rc.Name, "git",
-		"--git-dir", gitdir,
-		"--work-tree", rc.Path(),
-		"fetch", "origin", rc.Revision)
-	if err := fetch.Run(); err != nil {
+	f, err := rc.fetcher()
+	if err != nil {
 		return err
 	}
-
-	checkout := newCommand(rc.Name, "git",
-		"--git-dir", gitdir,
-		"--work-tree", rc.Path(),
-		"-c", "advice.detachedHead=false",
-		"checkout", "FETCH_HEAD")
-	return checkout.Run()
+	commit, err := f.Fetch(rc, rc.Revision)
+	if err != nil {
+		return err
+	}
+	return f.Checkout(rc, commit)
 }
 
-// LocalCatalog handles reading configuration locally.
+// LocalCatalog handles reading configuration locally. Paths are local file
+// paths.
 type LocalCatalog struct{}
 
 // Open returns the local file. This is a wrapper around os.Open.
-func (LocalCatalog) Open(path string) (*os.File, error) {
-	return openFile(path)
+func (LocalCatalog) Open(path string) (io.ReadCloser, error) {
+	return os.Open(path)
 }
 
-// openFile opens the given file, allowing for fuzzing of the extension.
-func openFile(path string) (*os.File, error) {
+// Stat is a wrapper around os.Stat.
+func (LocalCatalog) Stat(path string) (os.FileInfo, error) {
+	return os.Stat(path)
+}
+
+// ReadDir is a wrapper around ioutil.ReadDir.
+func (LocalCatalog) ReadDir(path string) ([]os.FileInfo, error) {
+	return ioutil.ReadDir(path)
+}
+
+// DirCatalog is a catalog rooted at a local directory.
+type DirCatalog string
+
+func (d DirCatalog) path(path string) string {
+	return filepath.Join(string(d), filepath.FromSlash(path))
+}
+
+// Open opens the file at path below the directory.
+func (d DirCatalog) Open(path string) (io.ReadCloser, error) {
+	return os.Open(d.path(path))
+}
+
+// Stat returns information about the file at path below the directory.
+func (d DirCatalog) Stat(path string) (os.FileInfo, error) {
+	return os.Stat(d.path(path))
+}
+
+// ReadDir returns the entries of the directory at path below the directory.
+func (d DirCatalog) ReadDir(path string) ([]os.FileInfo, error) {
+	return ioutil.ReadDir(d.path(path))
+}
+
+// hookFileExtensions are the extensions of hook files.
+var hookFileExtensions = []string{".yaml", ".yml", ".hook"}
+
+// hookExtensions are tried in order when opening hooks without an extension.
+var hookExtensions = append([]string{""}, hookFileExtensions...)
+
+// IsHookFile reports whether path looks like a hook file. Catalog manifests
+// are not hook files.
+func IsHookFile(path string) bool {
+	if filepath.Base(path) == ManifestFile {
+		return false
+	}
+	ext := filepath.Ext(path)
+	for _, e := range hookFileExtensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// OpenHook opens the hook file at path in the catalog, allowing for fuzzing
+// of the extension. It also returns the path of the file that was opened.
+func OpenHook(c Catalog, path string) (io.ReadCloser, string, error) {
 	if filepath.Ext(path) != "" {
-		return os.Open(path)
+		f, err := c.Open(path)
+		return f, path, err
 	}
 
 	var err error
-	for _, ext := range []string{"", ".yaml", ".yml"} {
-		var f *os.File
-		f, err = os.Open(path + ext)
-		if err == nil {
-			return f, nil
+	for _, ext := range hookExtensions {
+		var fi os.FileInfo
+		if fi, err = c.Stat(path + ext); err != nil {
+			continue
+		}
+		if fi.IsDir() {
+			err = fmt.Errorf("%s is a directory", path+ext)
+			continue
+		}
+		var f io.ReadCloser
+		if f, err = c.Open(path + ext); err == nil {
+			return f, path + ext, nil
+		}
+	}
+	return nil, "", err
+}
+
+// WalkCatalog calls fn for every hook file below root in the catalog, in
+// lexical order. If root is a file, fn is called for it regardless of its
+// extension. Directories starting with a dot are skipped.
+func WalkCatalog(c Catalog, root string, fn func(path string) error) error {
+	fi, err := c.Stat(root)
+	if os.IsNotExist(err) && filepath.Ext(root) == "" {
+		// Allow the extension to be omitted, like when firing.
+		for _, ext := range hookExtensions[1:] {
+			if fi, err = c.Stat(root + ext); err == nil {
+				root += ext
+				break
+			}
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return fn(root)
+	}
+	return walkCatalogDir(c, root, fn)
+}
+
+func walkCatalogDir(c Catalog, dir string, fn func(path string) error) error {
+	entries, err := c.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		p := e.Name()
+		if dir != "" {
+			p = strings.TrimSuffix(dir, "/") + "/" + e.Name()
+		}
+		switch {
+		case e.IsDir() && strings.HasPrefix(e.Name(), "."):
+		case e.IsDir():
+			if err := walkCatalogDir(c, p, fn); err != nil {
+				return err
+			}
+		case IsHookFile(p):
+			if err := fn(p); err != nil {
+				return err
+			}
 		}
 	}
-	return nil, err
+	return nil
 }
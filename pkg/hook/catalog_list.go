@@ -0,0 +1,260 @@
+package hook
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// eventHeaders match headers that carry the event type of a hook, e.g.
+// X-GitHub-Event, X-Event-Key, X-Shopify-Topic or ce-type.
+var eventHeaders = regexp.MustCompile(`(?i)^(x-.*-event|x-event-key|x-.*-topic|ce-type)$`)
+
+// CatalogEntry describes a hook file in a catalog.
+type CatalogEntry struct {
+	Catalog string `json:"catalog"`
+	// Path is the slash separated path of the file in the catalog.
+	Path string `json:"path"`
+	// Ref is the name to fire the hook with, e.g. @github/push.
+	Ref         string   `json:"ref"`
+	Hooks       int      `json:"hooks"`
+	Name        string   `json:"name,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Events      []string `json:"events,omitempty"`
+	// Error is set if the file could not be read.
+	Error string `json:"error,omitempty"`
+}
+
+// catalogRef returns the name of a hook file in a catalog, without the
+// extension OpenHook allows to be omitted.
+func catalogRef(catalog, p string) string {
+	for _, ext := range hookFileExtensions {
+		if path.Ext(p) == ext {
+			p = strings.TrimSuffix(p, ext)
+			break
+		}
+	}
+	if catalog == DefaultCatalog.Name {
+		return "@" + p
+	}
+	return catalog + "@" + p
+}
+
+// ListCatalog returns the hook files of the catalog whose paths start with
+// prefix. Files that can't be decoded are included with their error.
+func ListCatalog(c Catalog, catalog, prefix string) ([]*CatalogEntry, error) {
+	root := ""
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		root = prefix[:i]
+	}
+	if root != "" {
+		if _, err := c.Stat(root); os.IsNotExist(err) {
+			return nil, nil
+		}
+	}
+
+	var entries []*CatalogEntry
+	err := WalkCatalog(c, root, func(p string) error {
+		if !strings.HasPrefix(p, prefix) {
+			return nil
+		}
+		e := &CatalogEntry{Catalog: catalog, Path: p, Ref: catalogRef(catalog, p)}
+		entries = append(entries, e)
+
+		f, err := c.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		hooks, err := New(f)
+		if err != nil {
+			e.Error = err.Error()
+			return nil
+		}
+
+		e.Hooks = len(hooks)
+		seen := make(map[string]bool)
+		for _, h := range hooks {
+			if e.Name == "" {
+				e.Name = h.Name
+			}
+			if e.Description == "" {
+				e.Description = h.Description
+			}
+			for k, values := range h.Headers {
+				if !eventHeaders.MatchString(k) {
+					continue
+				}
+				for _, v := range values {
+					if !seen[v] {
+						seen[v] = true
+						e.Events = append(e.Events, v)
+					}
+				}
+			}
+		}
+		sort.Strings(e.Events)
+		return nil
+	})
+	return entries, err
+}
+
+// CatalogListing is the listing of a single catalog.
+type CatalogListing struct {
+	Catalog  string          `json:"catalog"`
+	URL      string          `json:"url"`
+	Manifest *Manifest       `json:"manifest,omitempty"`
+	Hooks    []*CatalogEntry `json:"hooks"`
+	// Error is set if the catalog could not be listed.
+	Error string `json:"error,omitempty"`
+}
+
+// ListCatalogs lists the hook files of the configured catalogs. arg selects
+// a catalog and a path prefix within it, either as <catalog>@[prefix] or as
+// <catalog>[/prefix]. An empty arg lists every configured catalog. Catalogs
+// pinned to a revision, see ParseRef, are listed at that revision. When
+// every catalog is listed, catalogs that can't be read are included with
+// their error.
+func ListCatalogs(arg string) ([]*CatalogListing, error) {
+	rcs, err := GetRemoteConfigs()
+	if err != nil {
+		return nil, err
+	}
+
+	var catalog, prefix, revision string
+	switch {
+	case strings.Contains(arg, "@"):
+		if catalog, prefix, revision, err = ParseRef(arg); err != nil {
+			return nil, err
+		}
+	case arg != "":
+		s := strings.SplitN(arg, "/", 2)
+		catalog = s[0]
+		if len(s) == 2 {
+			prefix = s[1]
+		}
+	}
+
+	names := make([]string, 0, len(rcs))
+	if catalog != "" {
+		if _, err := rcs.Get(catalog); err != nil {
+			return nil, err
+		}
+		names = append(names, catalog)
+	} else {
+		for n := range rcs {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+	}
+
+	// Catalogs are listed as they are read when fired.
+	lock, _, err := LoadLockfile()
+	if err != nil {
+		return nil, err
+	}
+
+	listings := make([]*CatalogListing, 0, len(names))
+	for _, n := range names {
+		l, err := listCatalog(rcs[n], lock, prefix, revision)
+		if err != nil {
+			if catalog != "" {
+				return nil, err
+			}
+			l = &CatalogListing{Catalog: n, URL: rcs[n].URL, Hooks: []*CatalogEntry{}, Error: err.Error()}
+		}
+		listings = append(listings, l)
+	}
+	return listings, nil
+}
+
+// listCatalog lists the hook files of rc whose paths start with prefix, at
+// revision if it's set.
+func listCatalog(rc *RemoteConfig, lock *Lockfile, prefix, revision string) (*CatalogListing, error) {
+	var c Catalog
+	var err error
+	if revision != "" {
+		c, err = rc.AtRevision(revision)
+	} else {
+		c, _, err = lock.remoteCatalog(rc)
+	}
+	if err != nil {
+		return nil, err
+	}
+	l := &CatalogListing{Catalog: rc.Name, URL: rc.URL}
+	if l.Hooks, err = ListCatalog(c, rc.Name, prefix); err != nil {
+		return nil, fmt.Errorf("listing catalog %s: %v", rc.Name, err)
+	}
+	if revision != "" {
+		for _, e := range l.Hooks {
+			e.Ref += "#" + revision
+		}
+	}
+	if l.Manifest, err = ReadManifest(c); err != nil {
+		return nil, fmt.Errorf("listing catalog %s: %v", rc.Name, err)
+	}
+	if l.Hooks == nil {
+		l.Hooks = []*CatalogEntry{}
+	}
+	return l, nil
+}
+
+// PrintCatalogListings writes the listings as a table per catalog, each
+// preceded by the catalog's description.
+func PrintCatalogListings(w io.Writer, listings []*CatalogListing) error {
+	for i, l := range listings {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%s %s\n", l.Catalog, l.URL)
+		if l.Error != "" {
+			fmt.Fprintf(w, "  error: %s\n", firstLine(l.Error))
+			continue
+		}
+		if m := l.Manifest; m != nil {
+			if m.Description != "" {
+				fmt.Fprintf(w, "  %s\n", firstLine(m.Description))
+			}
+			if len(m.Providers) > 0 {
+				fmt.Fprintf(w, "  providers: %s\n", strings.Join(m.Providers, ", "))
+			}
+			if len(m.Maintainers) > 0 {
+				fmt.Fprintf(w, "  maintainers: %s\n", strings.Join(m.Maintainers, ", "))
+			}
+		}
+		fmt.Fprintln(w)
+		if err := PrintCatalogEntries(w, l.Hooks); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PrintCatalogEntries writes entries as a table.
+func PrintCatalogEntries(w io.Writer, entries []*CatalogEntry) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "REF\tHOOKS\tEVENTS\tNAME")
+	for _, e := range entries {
+		name := e.Name
+		if name == "" {
+			name = firstLine(e.Description)
+		}
+		if e.Error != "" {
+			name = "error: " + firstLine(e.Error)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", e.Ref, e.Hooks, strings.Join(e.Events, ","), name)
+	}
+	return tw.Flush()
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
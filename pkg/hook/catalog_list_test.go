@@ -0,0 +1,203 @@
+package hook
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/viper"
+)
+
+var listFiles = map[string]string{
+	"github/push.yaml": `apiVersion: hook/v1
+kind: Hook
+name: push
+description: A push to a repository
+method: POST
+headers:
+  X-GitHub-Event:
+  - push
+---
+apiVersion: hook/v1
+kind: Hook
+method: POST
+headers:
+  X-GitHub-Event:
+  - push
+`,
+	"github/ping.yml": `method: GET
+headers:
+  X-GitHub-Event:
+  - ping
+`,
+	"stripe/charge.hook": `apiVersion: hook/v1
+kind: Hook
+description: |-
+  A charge
+  succeeded
+method: POST
+headers:
+  ce-type:
+  - charge.succeeded
+`,
+	"broken.yaml": "nope: 1\n",
+}
+
+func TestListCatalog(t *testing.T) {
+	catalogs, cleanup := testCatalogs(t, listFiles)
+	defer cleanup()
+
+	all := []*CatalogEntry{
+		{Catalog: "@", Path: "broken.yaml", Ref: "@broken", Error: "line 1: unknown field nope"},
+		{Catalog: "@", Path: "github/ping.yml", Ref: "@github/ping", Hooks: 1, Events: []string{"ping"}},
+		{Catalog: "@", Path: "github/push.yaml", Ref: "@github/push", Hooks: 2, Name: "push", Description: "A push to a repository", Events: []string{"push"}},
+		{Catalog: "@", Path: "stripe/charge.hook", Ref: "@stripe/charge", Hooks: 1, Description: "A charge\nsucceeded", Events: []string{"charge.succeeded"}},
+	}
+
+	for name, c := range catalogs {
+		for _, tc := range []struct {
+			prefix string
+			want   []*CatalogEntry
+		}{
+			{prefix: "", want: all},
+			{prefix: "github/", want: all[1:3]},
+			{prefix: "github/pu", want: all[2:3]},
+			{prefix: "str", want: all[3:]},
+			{prefix: "nope/", want: nil},
+		} {
+			got, err := ListCatalog(c, "@", tc.prefix)
+			if err != nil {
+				t.Errorf("%s: ListCatalog(%q): %v", name, tc.prefix, err)
+				continue
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s: ListCatalog(%q): %s", name, tc.prefix, diff)
+			}
+		}
+	}
+}
+
+func TestListCatalogs(t *testing.T) {
+	d := testdirInit(t)
+	defer os.RemoveAll(d)
+
+	c := &commandSink{}
+	c.install()
+	viper.Set("catalog.remote", []*RemoteConfig{
+		{Name: "foo", URL: "http://example.com/foo"},
+		{Name: "bar", URL: "http://example.com/bar"},
+		{Name: "baz", URL: "http://example.com/baz"},
+	})
+	rcs, err := GetRemoteConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rc := range rcs {
+		if err := rc.Clone(); err != nil {
+			t.Fatal(err)
+		}
+		if rc.Name == "baz" {
+			if err := ioutil.WriteFile(filepath.Join(rc.Path(), ManifestFile), []byte("nope: ["), 0644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := ioutil.WriteFile(filepath.Join(rc.Path(), "ping.yaml"), []byte(listFiles["github/ping.yml"]), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(rc.Path(), "charge.hook"), []byte(listFiles["stripe/charge.hook"]), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tc := range []struct {
+		arg  string
+		want []string
+	}{
+		{arg: "", want: []string{"bar@charge", "bar@ping", "foo@charge", "foo@ping"}},
+		{arg: "foo", want: []string{"foo@charge", "foo@ping"}},
+		{arg: "foo/pi", want: []string{"foo@ping"}},
+		{arg: "bar@p", want: []string{"bar@ping"}},
+		{arg: "bar@x", want: nil},
+	} {
+		listings, err := ListCatalogs(tc.arg)
+		if err != nil {
+			t.Errorf("ListCatalogs(%q): %v", tc.arg, err)
+			continue
+		}
+		var got []string
+		for _, l := range listings {
+			for _, e := range l.Hooks {
+				got = append(got, e.Ref)
+			}
+		}
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("ListCatalogs(%q): %s", tc.arg, diff)
+		}
+	}
+
+	// A catalog that can't be read doesn't keep the others from being listed.
+	listings, err := ListCatalogs("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range listings {
+		if failed := l.Error != ""; failed != (l.Catalog == "baz") {
+			t.Errorf("%s: unexpected error %q", l.Catalog, l.Error)
+		}
+	}
+	var out bytes.Buffer
+	if err := PrintCatalogListings(&out, listings); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "baz http://example.com/baz\n  error: ") {
+		t.Errorf("error not printed: %s", out.String())
+	}
+	if _, err := ListCatalogs("baz"); err == nil {
+		t.Error("expected error listing a broken catalog")
+	}
+
+	// Listed refs can be fired, whatever the extension of the file.
+	listings, err = ListCatalogs("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range listings[0].Hooks {
+		hooks, err := NewFromPath(e.Ref)
+		if err != nil {
+			t.Errorf("NewFromPath(%q): %v", e.Ref, err)
+			continue
+		}
+		if len(hooks) != e.Hooks {
+			t.Errorf("NewFromPath(%q): got %d hooks, want %d", e.Ref, len(hooks), e.Hooks)
+		}
+	}
+
+	if _, err := ListCatalogs("nope"); err == nil {
+		t.Error("expected error for unknown catalog")
+	}
+}
+
+func TestPrintCatalogEntries(t *testing.T) {
+	var b bytes.Buffer
+	err := PrintCatalogEntries(&b, []*CatalogEntry{
+		{Ref: "@github/push", Hooks: 2, Name: "push", Events: []string{"push"}},
+		{Ref: "@stripe/charge", Hooks: 1, Description: "A charge\nsucceeded", Events: []string{"a", "b"}},
+		{Ref: "@broken", Error: "line 1: unknown field nope"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `REF             HOOKS  EVENTS  NAME
+@github/push    2      push    push
+@stripe/charge  1      a,b     A charge
+@broken         0              error: line 1: unknown field nope
+`
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Error(diff)
+	}
+}
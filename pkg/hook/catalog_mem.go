@@ -0,0 +1,102 @@
+package hook
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MemCatalog is an in-memory catalog of file contents by slash separated
+// path.
+type MemCatalog map[string][]byte
+
+// clean normalizes p, with the root of the catalog as "".
+func (MemCatalog) clean(p string) string {
+	return strings.Trim(path.Clean("/"+p), "/")
+}
+
+// Open opens the file at p.
+func (m MemCatalog) Open(p string) (io.ReadCloser, error) {
+	b, ok := m[m.clean(p)]
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: p, Err: os.ErrNotExist}
+	}
+	return ioutil.NopCloser(bytes.NewReader(b)), nil
+}
+
+// Stat returns information about the file or directory at p.
+func (m MemCatalog) Stat(p string) (os.FileInfo, error) {
+	p = m.clean(p)
+	if b, ok := m[p]; ok {
+		return memFileInfo{name: path.Base(p), size: int64(len(b))}, nil
+	}
+	prefix := p + "/"
+	if p == "" {
+		prefix = ""
+	}
+	for k := range m {
+		if strings.HasPrefix(k, prefix) {
+			return memFileInfo{name: path.Base("/" + p), dir: true}, nil
+		}
+	}
+	return nil, &os.PathError{Op: "stat", Path: p, Err: os.ErrNotExist}
+}
+
+// ReadDir returns the entries of the directory at p, sorted by name.
+func (m MemCatalog) ReadDir(p string) ([]os.FileInfo, error) {
+	p = m.clean(p)
+	prefix := p + "/"
+	if p == "" {
+		prefix = ""
+	}
+
+	entries := make(map[string]memFileInfo)
+	for k, b := range m {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		rest := strings.TrimPrefix(k, prefix)
+		if i := strings.Index(rest, "/"); i >= 0 {
+			entries[rest[:i]] = memFileInfo{name: rest[:i], dir: true}
+			continue
+		}
+		entries[rest] = memFileInfo{name: rest, size: int64(len(b))}
+	}
+	if len(entries) == 0 {
+		return nil, &os.PathError{Op: "readdir", Path: p, Err: os.ErrNotExist}
+	}
+
+	out := make([]os.FileInfo, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, e)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Name() < out[j].Name()
+	})
+	return out, nil
+}
+
+// memFileInfo describes a file or directory of a MemCatalog.
+type memFileInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (fi memFileInfo) Name() string       { return fi.name }
+func (fi memFileInfo) Size() int64        { return fi.size }
+func (fi memFileInfo) ModTime() time.Time { return time.Time{} }
+func (fi memFileInfo) IsDir() bool        { return fi.dir }
+func (fi memFileInfo) Sys() interface{}   { return nil }
+
+func (fi memFileInfo) Mode() os.FileMode {
+	if fi.dir {
+		return os.ModeDir | 0555
+	}
+	return 0444
+}
@@ -0,0 +1,144 @@
+package hook
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// At returns the catalog as of the given revision. The catalog is cloned if
+// it isn't cached yet, but the revision has to be in the cache.
+func (rc *RemoteConfig) At(rev string) (Catalog, error) {
+	if _, err := rc.dir(); err != nil {
+		return nil, err
+	}
+	f, err := rc.fetcher()
+	if err != nil {
+		return nil, err
+	}
+	commit, err := f.Resolve(rc, rev)
+	if err != nil {
+		return nil, fmt.Errorf("catalog %s: revision %s is not in the cache, run hook catalog update", rc.Name, rev)
+	}
+	return f.At(rc, commit)
+}
+
+// AtRevision returns the catalog as of a revision, such as a tag, branch or
+// commit. Revisions that aren't in the cache are fetched, without changing
+// what the cache has checked out.
+func (rc *RemoteConfig) AtRevision(rev string) (Catalog, error) {
+	if c, err := rc.At(rev); err == nil {
+		return c, nil
+	}
+	f, err := rc.fetcher()
+	if err != nil {
+		return nil, err
+	}
+	commit, err := f.Fetch(rc, rev)
+	if err != nil {
+		return nil, err
+	}
+	return rc.At(commit)
+}
+
+// revCatalog reads the files of a cached catalog at a commit with the git
+// binary, regardless of what is checked out.
+type revCatalog struct {
+	rc     *RemoteConfig
+	commit string
+}
+
+// cleanTreePath normalizes p, with the root of the catalog as "".
+func cleanTreePath(p string) string {
+	return strings.Trim(path.Clean("/"+p), "/")
+}
+
+// Open reads the file at p from the commit.
+func (c *revCatalog) Open(p string) (io.ReadCloser, error) {
+	fi, err := c.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+	if fi.IsDir() {
+		return nil, &os.PathError{Op: "open", Path: p, Err: fmt.Errorf("is a directory")}
+	}
+	b, err := c.rc.git("show", c.commit+":"+cleanTreePath(p))
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(bytes.NewReader(b)), nil
+}
+
+// Stat returns information about the file or directory at p in the commit.
+func (c *revCatalog) Stat(p string) (os.FileInfo, error) {
+	p = cleanTreePath(p)
+	if p == "" {
+		return memFileInfo{name: "/", dir: true}, nil
+	}
+	entries, err := c.lsTree(p)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, &os.PathError{Op: "stat", Path: p, Err: os.ErrNotExist}
+	}
+	return entries[0], nil
+}
+
+// ReadDir returns the entries of the directory at p in the commit.
+func (c *revCatalog) ReadDir(p string) ([]os.FileInfo, error) {
+	p = cleanTreePath(p)
+	if p != "" {
+		p += "/"
+	}
+	entries, err := c.lsTree(p)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, &os.PathError{Op: "readdir", Path: p, Err: os.ErrNotExist}
+	}
+	out := make([]os.FileInfo, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, e)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Name() < out[j].Name()
+	})
+	return out, nil
+}
+
+// lsTree lists the tree entries matching p. Paths ending in a slash list the
+// contents of the directory.
+func (c *revCatalog) lsTree(p string) ([]memFileInfo, error) {
+	args := []string{"ls-tree", "-z", "-l", c.commit}
+	if p != "" {
+		args = append(args, "--", p)
+	}
+	out, err := c.rc.git(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []memFileInfo
+	for _, line := range strings.Split(string(out), "\x00") {
+		// <mode> SP <type> SP <object> SP+ <size> TAB <path>
+		tab := strings.IndexByte(line, '\t')
+		if tab < 0 {
+			continue
+		}
+		fields := strings.Fields(line[:tab])
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("unexpected git ls-tree output %q", line)
+		}
+		fi := memFileInfo{name: path.Base(line[tab+1:]), dir: fields[1] == "tree"}
+		fmt.Sscan(fields[3], &fi.size)
+		entries = append(entries, fi)
+	}
+	return entries, nil
+}
@@ -0,0 +1,308 @@
+package hook
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/tidwall/gjson"
+)
+
+// Index is the search index of the cached catalogs, stored in the cache
+// directory. Files are only decoded again if their size or modification time
+// changed since they were indexed.
+type Index struct {
+	// Catalogs maps catalog names to their files by path.
+	Catalogs map[string]map[string]*IndexEntry `json:"catalogs"`
+	// Sources maps catalog names to where they were indexed from, such as a
+	// locked commit, so that they are indexed again if that changes.
+	Sources map[string]string `json:"sources,omitempty"`
+}
+
+// IndexEntry holds the searchable text of a hook file.
+type IndexEntry struct {
+	Ref         string    `json:"ref"`
+	Size        int64     `json:"size"`
+	ModTime     time.Time `json:"modTime"`
+	Name        string    `json:"name,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Events      []string  `json:"events,omitempty"`
+	// Headers are the header values of every document.
+	Headers []string `json:"headers,omitempty"`
+	// Body are the keys and values of JSON bodies, or other bodies verbatim.
+	Body []string `json:"body,omitempty"`
+}
+
+// SearchResult is a hook file matching a search.
+type SearchResult struct {
+	Ref         string `json:"ref"`
+	Score       int    `json:"score"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// searchWeights are the scores of a query term matching a field. Each term
+// scores the weight of the best field it matches.
+var searchWeights = []struct {
+	weight int
+	values func(e *IndexEntry) []string
+}{
+	{4, func(e *IndexEntry) []string { return []string{e.Ref} }},
+	{3, func(e *IndexEntry) []string { return append([]string{e.Name}, e.Events...) }},
+	{2, func(e *IndexEntry) []string { return append([]string{e.Description}, e.Headers...) }},
+	{1, func(e *IndexEntry) []string { return e.Body }},
+}
+
+func indexPath() string {
+	return filepath.Join(viper.GetString("cache"), ".index.json")
+}
+
+// LoadIndex reads the search index from the cache directory. A missing index
+// is empty.
+func LoadIndex() (*Index, error) {
+	ix := &Index{Catalogs: make(map[string]map[string]*IndexEntry)}
+	b, err := ioutil.ReadFile(indexPath())
+	if os.IsNotExist(err) {
+		return ix, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, ix); err != nil || ix.Catalogs == nil {
+		// The index is rebuilt if it can't be read.
+		ix.Catalogs = make(map[string]map[string]*IndexEntry)
+	}
+	return ix, nil
+}
+
+// Save writes the search index to the cache directory.
+func (ix *Index) Save() error {
+	b, err := json.Marshal(ix)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(indexPath()), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(indexPath(), b, 0644)
+}
+
+// Update brings the index up to date with the cached catalogs of rcs, read
+// as they are when fired: at the commits locked in lock, or from the embedded
+// snapshot if the default catalog isn't cached. Catalogs that are no longer
+// configured or cached are dropped. Catalogs that can't be read are logged
+// and keep the entries they were last indexed with.
+func (ix *Index) Update(rcs RemoteConfigSet, lock *Lockfile) {
+	if ix.Sources == nil {
+		ix.Sources = make(map[string]string)
+	}
+	for name := range ix.Catalogs {
+		if rc, ok := rcs[name]; !ok || (!rc.isCached() && rc.snapshot() == nil) {
+			delete(ix.Catalogs, name)
+			delete(ix.Sources, name)
+		}
+	}
+	for name, rc := range rcs {
+		c, src, err := indexedCatalog(rc, lock)
+		if err != nil {
+			log.Printf("indexing catalog %s: %v", name, err)
+			continue
+		}
+		if c == nil {
+			continue
+		}
+		old := ix.Catalogs[name]
+		if ix.Sources[name] != src {
+			// Sizes and modification times only tell changes apart within
+			// the same source.
+			old = nil
+		}
+		files, err := indexCatalog(c, name, old)
+		if err != nil {
+			log.Printf("indexing catalog %s: %v", name, err)
+			continue
+		}
+		ix.Catalogs[name] = files
+		ix.Sources[name] = src
+	}
+}
+
+// indexedCatalog returns the catalog of rc that is indexed and a description
+// of its source, or nil if rc isn't cached and has no snapshot. Catalogs
+// aren't cloned, so that searching works offline.
+func indexedCatalog(rc *RemoteConfig, lock *Lockfile) (Catalog, string, error) {
+	if !rc.isCached() {
+		s := rc.snapshot()
+		if s == nil {
+			return nil, "", nil
+		}
+		if err := lock.checkSnapshot(rc, s); err != nil {
+			return nil, "", err
+		}
+		return s, "embedded snapshot " + s.Revision, nil
+	}
+	c, src, err := lock.remoteCatalog(rc)
+	if err != nil {
+		return nil, "", err
+	}
+	return c, src.from + " " + src.rev, nil
+}
+
+// indexCatalog returns the entries of the files of c, reusing the entries of
+// old for files that have not changed.
+func indexCatalog(c Catalog, catalog string, old map[string]*IndexEntry) (map[string]*IndexEntry, error) {
+	files := make(map[string]*IndexEntry)
+	err := WalkCatalog(c, "", func(p string) error {
+		fi, err := c.Stat(p)
+		if err != nil {
+			return err
+		}
+		if e, ok := old[p]; ok && e.Size == fi.Size() && e.ModTime.Equal(fi.ModTime()) {
+			files[p] = e
+			return nil
+		}
+
+		e := &IndexEntry{Ref: catalogRef(catalog, p), Size: fi.Size(), ModTime: fi.ModTime()}
+		files[p] = e
+		f, err := c.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		hooks, err := New(f)
+		if err != nil {
+			// Keep the entry so the file isn't decoded again until it
+			// changes.
+			return nil
+		}
+		e.add(hooks)
+		return nil
+	})
+	return files, err
+}
+
+// add adds the searchable text of hooks to the entry.
+func (e *IndexEntry) add(hooks []*Hook) {
+	for _, h := range hooks {
+		if e.Name == "" {
+			e.Name = h.Name
+		}
+		if e.Description == "" {
+			e.Description = h.Description
+		}
+		for k, values := range h.Headers {
+			if eventHeaders.MatchString(k) {
+				e.Events = append(e.Events, values...)
+			}
+			e.Headers = append(e.Headers, values...)
+		}
+		if h.Body == "" {
+			continue
+		}
+		if !gjson.Valid(h.Body) {
+			e.Body = append(e.Body, h.Body)
+			continue
+		}
+		var walk func(v gjson.Result)
+		walk = func(v gjson.Result) {
+			v.ForEach(func(k, v gjson.Result) bool {
+				if k.Exists() {
+					e.Body = append(e.Body, k.String())
+				}
+				if v.IsObject() || v.IsArray() {
+					walk(v)
+				} else {
+					e.Body = append(e.Body, v.String())
+				}
+				return true
+			})
+		}
+		walk(gjson.Parse(h.Body))
+	}
+	sort.Strings(e.Events)
+	sort.Strings(e.Headers)
+}
+
+// score returns the score of the entry for the query terms, or 0 if a term
+// doesn't match.
+func (e *IndexEntry) score(terms []string) int {
+	total := 0
+	for _, t := range terms {
+		best := 0
+		for _, w := range searchWeights {
+			if w.weight <= best {
+				continue
+			}
+			for _, v := range w.values(e) {
+				if strings.Contains(strings.ToLower(v), t) {
+					best = w.weight
+					break
+				}
+			}
+		}
+		if best == 0 {
+			return 0
+		}
+		total += best
+	}
+	return total
+}
+
+// Search returns the entries matching every whitespace separated term of the
+// query, case insensitively, ordered by score.
+func (ix *Index) Search(query string) []*SearchResult {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return nil
+	}
+
+	var results []*SearchResult
+	for _, files := range ix.Catalogs {
+		for _, e := range files {
+			if s := e.score(terms); s > 0 {
+				results = append(results, &SearchResult{Ref: e.Ref, Score: s, Name: e.Name, Description: e.Description})
+			}
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Ref < results[j].Ref
+	})
+	return results
+}
+
+// UpdateIndex brings the search index of the cached catalogs up to date.
+func UpdateIndex() (*Index, error) {
+	rcs, err := GetRemoteConfigs()
+	if err != nil {
+		return nil, err
+	}
+	lock, _, err := LoadLockfile()
+	if err != nil {
+		return nil, err
+	}
+	ix, err := LoadIndex()
+	if err != nil {
+		return nil, err
+	}
+	ix.Update(rcs, lock)
+	return ix, ix.Save()
+}
+
+// SearchCatalogs searches the hooks of the cached catalogs, updating the
+// index first.
+func SearchCatalogs(query string) ([]*SearchResult, error) {
+	ix, err := UpdateIndex()
+	if err != nil {
+		return nil, err
+	}
+	return ix.Search(query), nil
+}
@@ -0,0 +1,217 @@
+package hook
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/viper"
+)
+
+var searchFiles = map[string]string{
+	"stripe/invoice.yaml": `apiVersion: hook/v1
+kind: Hook
+name: invoice
+description: An invoice was paid
+method: POST
+headers:
+  Content-Type:
+  - application/json
+body: '{"type": "invoice.paid", "data": {"object": {"amount_paid": 2000}}}'
+`,
+	"stripe/charge.yaml": `apiVersion: hook/v1
+kind: Hook
+method: POST
+headers:
+  Content-Type:
+  - application/json
+body: '{"type": "charge.succeeded", "data": {"object": {"invoice": "in_123"}}}'
+`,
+	"github/push.yaml": `apiVersion: hook/v1
+kind: Hook
+method: POST
+headers:
+  X-GitHub-Event:
+  - push
+body: ref=refs/heads/master
+`,
+}
+
+func TestIndexSearch(t *testing.T) {
+	catalogs, cleanup := testCatalogs(t, searchFiles)
+	defer cleanup()
+
+	var err error
+	ix := &Index{Catalogs: make(map[string]map[string]*IndexEntry)}
+	if ix.Catalogs["@"], err = indexCatalog(catalogs["mem"], "@", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		query string
+		want  []*SearchResult
+	}{
+		{
+			query: "Stripe invoice.paid",
+			want: []*SearchResult{
+				{Ref: "@stripe/invoice", Score: 5, Name: "invoice", Description: "An invoice was paid"},
+			},
+		},
+		{
+			query: "invoice",
+			want: []*SearchResult{
+				{Ref: "@stripe/invoice", Score: 4, Name: "invoice", Description: "An invoice was paid"},
+				{Ref: "@stripe/charge", Score: 1},
+			},
+		},
+		{
+			query: "push",
+			want:  []*SearchResult{{Ref: "@github/push", Score: 4}},
+		},
+		{
+			query: "master",
+			want:  []*SearchResult{{Ref: "@github/push", Score: 1}},
+		},
+		{query: "stripe nope", want: nil},
+		{query: " ", want: nil},
+	} {
+		if diff := cmp.Diff(tc.want, ix.Search(tc.query)); diff != "" {
+			t.Errorf("Search(%q): %s", tc.query, diff)
+		}
+	}
+}
+
+func TestUpdateIndex(t *testing.T) {
+	d := testdirInit(t)
+	defer os.RemoveAll(d)
+
+	c := &commandSink{}
+	c.install()
+	viper.Set("catalog.remote", []*RemoteConfig{
+		{Name: "foo", URL: "http://example.com/foo"},
+		{Name: "bar", URL: "http://example.com/bar"},
+	})
+	rc, err := GetRemoteConfig("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rc.Clone(); err != nil {
+		t.Fatal(err)
+	}
+	write := func(p, data string) {
+		t.Helper()
+		path := filepath.Join(rc.Path(), filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for p, data := range searchFiles {
+		write(p, data)
+	}
+
+	// Uncached catalogs aren't cloned or indexed.
+	ix, err := UpdateIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ix.Catalogs["bar"]; ok {
+		t.Error("uncached catalog bar was indexed")
+	}
+	if got := ix.Search("invoice.paid"); len(got) != 1 || got[0].Ref != "foo@stripe/invoice" {
+		t.Errorf("Search: got %+v", got)
+	}
+
+	// Only changed files are indexed again.
+	ix, err = LoadIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	push := ix.Catalogs["foo"]["github/push.yaml"]
+	write("stripe/invoice.yaml", searchFiles["stripe/invoice.yaml"]+"vars:\n  event: invoice.created\n")
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(rc.Path(), "stripe", "invoice.yaml"), future, future); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(rc.Path(), "stripe", "charge.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	rcs, err := GetRemoteConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ix.Update(rcs, nil)
+	if ix.Catalogs["foo"]["github/push.yaml"] != push {
+		t.Error("unchanged file was indexed again")
+	}
+	if _, ok := ix.Catalogs["foo"]["stripe/charge.yaml"]; ok {
+		t.Error("removed file is still indexed")
+	}
+	if !ix.Catalogs["foo"]["stripe/invoice.yaml"].ModTime.Equal(future) {
+		t.Error("changed file was not indexed again")
+	}
+
+	// A catalog that can't be read keeps its entries and doesn't keep the
+	// others from being indexed.
+	bar, err := GetRemoteConfig("bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bar.Clone(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(bar.Path(), "ping.yaml"), []byte(searchFiles["github/push.yaml"]), 0644); err != nil {
+		t.Fatal(err)
+	}
+	invoice := ix.Catalogs["foo"]["stripe/invoice.yaml"]
+	ix.Update(rcs, &Lockfile{Catalogs: map[string]*LockedCatalog{
+		"foo": {URL: "http://example.com/moved", Commit: "abc123"},
+	}})
+	if ix.Catalogs["foo"]["stripe/invoice.yaml"] != invoice {
+		t.Error("entries of unreadable catalog foo were not kept")
+	}
+	if _, ok := ix.Catalogs["bar"]["ping.yaml"]; !ok {
+		t.Error("catalog bar was not indexed")
+	}
+
+	// Catalogs that are no longer configured are dropped.
+	viper.Set("catalog.remote", []*RemoteConfig{{Name: "baz", URL: "http://example.com/baz"}})
+	if ix, err = UpdateIndex(); err != nil {
+		t.Fatal(err)
+	}
+	if len(ix.Catalogs) != 0 {
+		t.Errorf("got catalogs %v, want none", ix.Catalogs)
+	}
+}
+
+func TestUpdateIndexSnapshot(t *testing.T) {
+	d := testdirInit(t)
+	defer os.RemoveAll(d)
+	defer func() { Offline = false }()
+	Offline = true
+
+	snapshot := defaultSnapshot
+	defer func() { defaultSnapshot = snapshot }()
+	defaultSnapshot = &snapshotCatalog{
+		MemCatalog: MemCatalog{"github/push.yaml": []byte(searchFiles["github/push.yaml"])},
+		Revision:   "abc123",
+	}
+	viper.Set("catalog.remote", []*RemoteConfig{DefaultCatalog})
+
+	// The snapshot of the uncached default catalog is searched.
+	got, err := SearchCatalogs("push")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]*SearchResult{{Ref: "@github/push", Score: 4}}, got); diff != "" {
+		t.Error(diff)
+	}
+	if DefaultCatalog.isCached() {
+		t.Error("default catalog was cached")
+	}
+}
@@ -3,22 +3,60 @@ package hook
 import (
 	"fmt"
 	"io"
+
+	"gopkg.in/yaml.v2"
 )
 
-// ShowHook writes the given hooks specified by the URI to a Writer.
+// ShowHook writes the given hooks specified by the URI to a Writer. Hooks
+// from catalogs include the defaults of the catalog's manifest and are
+// preceded by a comment with the source they were read from, and <catalog>@
+// shows the manifest itself.
 func ShowHook(w io.Writer, uri ...string) error {
 	for _, u := range uri {
-		hooks, err := NewFromPath(u)
+		c, path, src, err := resolveCatalog(u)
+		if err != nil {
+			return err
+		}
+		if src != nil && path == "" {
+			if err := showManifest(w, c, src); err != nil {
+				return err
+			}
+			continue
+		}
+
+		hooks, err := newFromCatalog(c, path)
 		if err != nil {
 			return err
 		}
+		header := "---\n"
+		if src != nil {
+			header += fmt.Sprintf("# %s\n", src)
+		}
 		for _, h := range hooks {
-			b, err := h.Dump()
+			b, err := h.dump()
 			if err != nil {
 				return err
 			}
-			fmt.Fprintf(w, "---\n%s", b)
+			fmt.Fprintf(w, "%s%s", header, b)
 		}
 	}
 	return nil
 }
+
+func showManifest(w io.Writer, c Catalog, src *catalogSource) error {
+	m, err := ReadManifest(c)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "---\n# %s\n", src)
+	if m == nil {
+		fmt.Fprintf(w, "# no %s\n", ManifestFile)
+		return nil
+	}
+	b, err := yaml.Marshal(m)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
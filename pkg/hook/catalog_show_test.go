@@ -15,6 +15,8 @@ func TestShowHook(t *testing.T) {
 	}
 
 	want := `---
+apiVersion: hook/v1
+kind: Hook
 method: POST
 headers:
   foo:
@@ -0,0 +1,44 @@
+package hook
+
+import (
+	"errors"
+	"log"
+)
+
+// snapshotCatalog is a read-only snapshot of a catalog embedded in hook.
+type snapshotCatalog struct {
+	MemCatalog
+	// Revision is the commit the snapshot was taken at, if known.
+	Revision string
+}
+
+// snapshot returns the embedded snapshot of the catalog, or nil if there is
+// none. Only the default catalog has a snapshot, generated by
+// tools/snapshotgen.
+func (rc *RemoteConfig) snapshot() *snapshotCatalog {
+	if rc.URL != DefaultCatalog.URL || defaultSnapshot == nil || len(defaultSnapshot.MemCatalog) == 0 {
+		return nil
+	}
+	return defaultSnapshot
+}
+
+// cachedOrSnapshot returns the catalog, cloning it if it isn't cached yet. If
+// it can't be cloned, such as when offline, the embedded snapshot of the
+// catalog is returned instead if it has one.
+func (rc *RemoteConfig) cachedOrSnapshot() (Catalog, error) {
+	if rc.isCached() {
+		return rc, nil
+	}
+	err := rc.Clone()
+	if err == nil {
+		return rc, nil
+	}
+	s := rc.snapshot()
+	if s == nil {
+		return nil, err
+	}
+	if !errors.Is(err, ErrOffline) {
+		log.Printf("%v, using the embedded snapshot", err)
+	}
+	return s, nil
+}
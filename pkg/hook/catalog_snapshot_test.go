@@ -0,0 +1,162 @@
+package hook
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestDefaultSnapshot(t *testing.T) {
+	// The shipped snapshot has to be usable, see tools/snapshotgen.
+	if len(defaultSnapshot.MemCatalog) == 0 {
+		t.Fatal("the embedded snapshot of the default catalog is empty")
+	}
+	if defaultSnapshot.Revision == "" {
+		t.Error("the embedded snapshot of the default catalog has no revision")
+	}
+	if DefaultCatalog.snapshot() != defaultSnapshot {
+		t.Error("the default catalog doesn't use the embedded snapshot")
+	}
+	f, p, err := OpenHook(defaultSnapshot, "github/push")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hooks, err := New(f)
+	f.Close()
+	if err != nil {
+		t.Fatalf("%s: %v", p, err)
+	}
+	if len(hooks) == 0 || hooks[0].Method == "" {
+		t.Errorf("%s: got %+v", p, hooks)
+	}
+	if _, err := ReadManifest(defaultSnapshot); err != nil {
+		t.Error(err)
+	}
+	if err := WalkCatalog(defaultSnapshot, "", func(p string) error {
+		diags, err := Lint(p, bytes.NewReader(defaultSnapshot.MemCatalog[p]))
+		for _, d := range diags {
+			t.Error(d)
+		}
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSnapshotCatalog(t *testing.T) {
+	d := testdirInit(t)
+	defer os.RemoveAll(d)
+	defer chdir(t, d)()
+
+	// Clones of the default catalog fail, as nothing is at its URL.
+	defaultURL, snapshot := DefaultCatalog.URL, defaultSnapshot
+	defer func() {
+		DefaultCatalog.URL, defaultSnapshot = defaultURL, snapshot
+	}()
+	DefaultCatalog.URL = "file://" + filepath.ToSlash(filepath.Join(d, "missing.tar.gz"))
+	defaultSnapshot = &snapshotCatalog{
+		MemCatalog: MemCatalog{"github/push.yaml": []byte("method: POST\n")},
+		Revision:   "abc123",
+	}
+
+	hooks, err := NewFromPath("@github/push")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hooks[0].Method != "POST" {
+		t.Errorf("got method %s, want POST", hooks[0].Method)
+	}
+	if DefaultCatalog.isCached() {
+		t.Error("default catalog was cached")
+	}
+	var out bytes.Buffer
+	if err := ShowHook(&out, "@github/push"); err != nil {
+		t.Fatal(err)
+	}
+	want := "---\n# @ " + DefaultCatalog.URL + " (embedded snapshot at abc123)\n"
+	if !strings.HasPrefix(out.String(), want) {
+		t.Errorf("ShowHook = %s, want prefix %s", out.String(), want)
+	}
+	listings, err := ListCatalogs("@")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listings[0].Hooks) != 1 || listings[0].Hooks[0].Ref != "@github/push" {
+		t.Errorf("got hooks %+v", listings[0].Hooks)
+	}
+
+	// The snapshot isn't used if the project locked another commit.
+	lock := &Lockfile{Catalogs: map[string]*LockedCatalog{
+		"@": {URL: DefaultCatalog.URL, Commit: "def456"},
+	}}
+	if err := lock.Write(filepath.Join(d, LockfileName)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFromPath("@github/push"); err == nil {
+		t.Error("expected an error for a snapshot of another commit")
+	}
+	lock.Catalogs["@"].Commit = "abc123"
+	if err := lock.Write(filepath.Join(d, LockfileName)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFromPath("@github/push"); err != nil {
+		t.Error(err)
+	}
+
+	// Other catalogs have no snapshot.
+	viper.Set("catalog.remote", []*RemoteConfig{DefaultCatalog, {Name: "other", URL: DefaultCatalog.URL + "?other"}})
+	if _, err := NewFromPath("other@github/push"); !errors.Is(err, ErrRefNotFound) {
+		t.Errorf("got %v, want ErrRefNotFound", err)
+	}
+}
+
+func TestOffline(t *testing.T) {
+	d := testdirInit(t)
+	defer os.RemoveAll(d)
+	defer func() { Offline = false }()
+	Offline = true
+
+	snapshot := defaultSnapshot
+	defer func() { defaultSnapshot = snapshot }()
+	defaultSnapshot = &snapshotCatalog{MemCatalog: MemCatalog{"github/push.yaml": []byte("method: POST\n")}}
+
+	// The default catalog is read from its snapshot without trying to clone
+	// it.
+	if _, err := NewFromPath("@github/push"); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := ShowHook(&out, "@github/push"); err != nil {
+		t.Fatal(err)
+	}
+	if want := "---\n# @ " + DefaultCatalog.URL + " (embedded snapshot)\n"; !strings.HasPrefix(out.String(), want) {
+		t.Errorf("ShowHook = %s, want prefix %s", out.String(), want)
+	}
+
+	// Cached catalogs can be used, but not updated.
+	rc := &RemoteConfig{Name: "cached", URL: "https://example.com/cached.zip"}
+	if err := os.MkdirAll(filepath.Join(rc.Path(), "github"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(filepath.Join(rc.Path(), "github", "push.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	missing := &RemoteConfig{Name: "missing", URL: "https://example.com/missing"}
+	viper.Set("catalog.remote", []*RemoteConfig{rc, missing})
+	if _, err := NewFromPath("cached@github/push"); err != nil {
+		t.Error(err)
+	}
+	if err := rc.Update(); !errors.Is(err, ErrOffline) {
+		t.Errorf("got %v, want ErrOffline", err)
+	}
+	if _, err := NewFromPath("missing@github/push"); !errors.Is(err, ErrOffline) {
+		t.Errorf("got %v, want ErrOffline", err)
+	}
+}
@@ -2,6 +2,7 @@ package hook
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sort"
@@ -9,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/viper"
 )
 
 func TestParsePath(t *testing.T) {
@@ -43,6 +45,35 @@ func TestParsePath(t *testing.T) {
 	}
 }
 
+func TestParseRef(t *testing.T) {
+	tests := []struct {
+		in, catalog, path, revision string
+	}{
+		{in: "@github/push", catalog: "@", path: "github/push"},
+		{in: "@github/push#v1.2", catalog: "@", path: "github/push", revision: "v1.2"},
+		{in: "github:abc123@push", catalog: "github", path: "push", revision: "abc123"},
+		{in: ":abc123@github/push", catalog: "@", path: "github/push", revision: "abc123"},
+		{in: "foo@#main", catalog: "foo", path: "", revision: "main"},
+		{in: "local/a#b", catalog: "", path: "local/a#b"},
+	}
+	for _, tc := range tests {
+		catalog, path, revision, err := ParseRef(tc.in)
+		if err != nil {
+			t.Errorf("ParseRef(%s): %v", tc.in, err)
+			continue
+		}
+		if catalog != tc.catalog || path != tc.path || revision != tc.revision {
+			t.Errorf("ParseRef(%s) = (%s, %s, %s), want (%s, %s, %s)", tc.in, catalog, path, revision, tc.catalog, tc.path, tc.revision)
+		}
+	}
+
+	for _, in := range []string{"github:@push", "@push#", "github:v1@push#v2"} {
+		if _, _, _, err := ParseRef(in); err == nil {
+			t.Errorf("ParseRef(%s): expected error", in)
+		}
+	}
+}
+
 // commandSink captures and stores commands created during execution.
 // It guarantees that the returned commands will be consistently ordered by repo
 // and by command order.
@@ -50,6 +81,9 @@ func TestParsePath(t *testing.T) {
 // ordering.
 type commandSink struct {
 	cmd []*mockCommand
+	// output returns the output of commands run for their output. If it is
+	// nil, commands succeed without output.
+	output func(args []string) ([]byte, error)
 }
 
 func (c *commandSink) reset() {
@@ -79,11 +113,18 @@ func (c *commandSink) commands() [][]string {
 	return out
 }
 
+// install makes the exec fetcher run commands through the sink.
+func (c *commandSink) install() {
+	newCommand = c.record
+	viper.Set("catalog.fetcher", "exec")
+}
+
 func (c *commandSink) record(name, command string, args ...string) runnable {
 	cmd := &mockCommand{
-		name: name,
-		idx:  len(args),
-		args: append([]string{command}, args...),
+		name:   name,
+		idx:    len(c.cmd),
+		args:   append([]string{command}, args...),
+		output: c.output,
 	}
 	c.cmd = append(c.cmd, cmd)
 	return cmd
@@ -101,14 +142,19 @@ type mockCommand struct {
 	idx int
 	// Command arguments that were called.
 	args []string
+	// Output of the command, see commandSink.
+	output func(args []string) ([]byte, error)
 }
 
-func (c mockCommand) Run() error {
+func (c mockCommand) Output() ([]byte, error) {
 	if c.args[1] == "clone" {
-		dir := c.args[3]
-		return os.MkdirAll(dir, 0700)
+		dir := c.args[len(c.args)-1]
+		return nil, os.MkdirAll(dir, 0700)
+	}
+	if c.output == nil {
+		return nil, nil
 	}
-	return nil
+	return c.output(c.args)
 }
 
 func (c mockCommand) String() string {
@@ -119,8 +165,10 @@ func TestCatalogUpdate(t *testing.T) {
 	d := testdirInit(t)
 	defer os.RemoveAll(d)
 
-	c := &commandSink{}
-	newCommand = c.record
+	c := &commandSink{output: func(args []string) ([]byte, error) {
+		return []byte("abc123\n"), nil
+	}}
+	c.install()
 
 	tc := []*RemoteConfig{
 		{
@@ -141,9 +189,9 @@ func TestCatalogUpdate(t *testing.T) {
 				t.Fatal(err)
 			}
 			workdir := filepath.Join(cachedir(t), rc.Name)
-			cloneCmd := []string{"git", "clone", rc.URL, workdir}
+			cloneCmd := []string{"git", "clone", "-q", rc.URL, workdir}
 			if rc.Revision != "" {
-				cloneCmd = []string{"git", "clone", "-b", rc.Revision, rc.URL, workdir}
+				cloneCmd = []string{"git", "clone", "-q", "-b", rc.Revision, rc.URL, workdir}
 			}
 			if diff := cmp.Diff([][]string{cloneCmd}, c.commands()); diff != "" {
 				t.Error(diff)
@@ -156,20 +204,20 @@ func TestCatalogUpdate(t *testing.T) {
 			}
 
 			gitdir := filepath.Join(workdir, ".git")
+			fetchCmd := []string{"git", "--git-dir", gitdir, "fetch", "-q", "origin"}
+			if rc.Revision != "" {
+				fetchCmd = append(fetchCmd, rc.Revision)
+			}
 			want := [][]string{
 				cloneCmd,
-				{
-					"git",
-					"--git-dir", gitdir,
-					"--work-tree", workdir,
-					"fetch", "origin", rc.Revision,
-				},
+				fetchCmd,
+				{"git", "--git-dir", gitdir, "rev-parse", "FETCH_HEAD"},
 				{
 					"git",
 					"--git-dir", gitdir,
 					"--work-tree", workdir,
 					"-c", "advice.detachedHead=false",
-					"checkout", "FETCH_HEAD",
+					"checkout", "-q", "abc123",
 				},
 			}
 			if diff := cmp.Diff(want, c.commands()); diff != "" {
@@ -180,3 +228,193 @@ func TestCatalogUpdate(t *testing.T) {
 		})
 	}
 }
+
+// testCatalogs returns the same files as a MemCatalog and a DirCatalog.
+func testCatalogs(t *testing.T, files map[string]string) (map[string]Catalog, func()) {
+	t.Helper()
+	d, err := ioutil.TempDir("", "hook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mem := MemCatalog{}
+	for p, data := range files {
+		mem[p] = []byte(data)
+		path := filepath.Join(d, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return map[string]Catalog{"mem": mem, "dir": DirCatalog(d)}, func() { os.RemoveAll(d) }
+}
+
+func TestOpenHook(t *testing.T) {
+	catalogs, cleanup := testCatalogs(t, map[string]string{
+		"github/push.yaml":  "method: POST\n",
+		"github/ping.yml":   "method: GET\n",
+		"stripe/charge":     "method: PUT\n",
+		"stripe/charge.yml": "method: DELETE\n",
+		"twilio/sms.hook":   "method: POST\n",
+	})
+	defer cleanup()
+
+	tests := []struct {
+		in, want string
+	}{
+		{in: "github/push", want: "github/push.yaml"},
+		{in: "github/ping", want: "github/ping.yml"},
+		{in: "github/push.yaml", want: "github/push.yaml"},
+		{in: "stripe/charge", want: "stripe/charge"},
+		{in: "twilio/sms", want: "twilio/sms.hook"},
+	}
+	for name, c := range catalogs {
+		for _, tc := range tests {
+			f, got, err := OpenHook(c, tc.in)
+			if err != nil {
+				t.Errorf("%s: OpenHook(%s): %v", name, tc.in, err)
+				continue
+			}
+			f.Close()
+			if got != tc.want {
+				t.Errorf("%s: OpenHook(%s) opened %s, want %s", name, tc.in, got, tc.want)
+			}
+		}
+
+		for _, in := range []string{"github", "github/nope", "nope/push"} {
+			if _, _, err := OpenHook(c, in); err == nil {
+				t.Errorf("%s: OpenHook(%s): expected error", name, in)
+			}
+		}
+	}
+}
+
+func TestWalkCatalog(t *testing.T) {
+	catalogs, cleanup := testCatalogs(t, map[string]string{
+		"b.yaml":          "",
+		"a/z.yml":         "",
+		"a/b/c.hook":      "",
+		"a/README.md":     "",
+		".git/HEAD.yaml":  "",
+		"single.json":     "",
+		"deep/x/y/z.yaml": "",
+	})
+	defer cleanup()
+
+	for name, c := range catalogs {
+		for _, tc := range []struct {
+			root string
+			want []string
+		}{
+			{root: "", want: []string{"a/b/c.hook", "a/z.yml", "b.yaml", "deep/x/y/z.yaml"}},
+			{root: "a", want: []string{"a/b/c.hook", "a/z.yml"}},
+			{root: "b", want: []string{"b.yaml"}},
+			{root: "single.json", want: []string{"single.json"}},
+		} {
+			var got []string
+			err := WalkCatalog(c, tc.root, func(p string) error {
+				got = append(got, p)
+				return nil
+			})
+			if err != nil {
+				t.Errorf("%s: WalkCatalog(%q): %v", name, tc.root, err)
+				continue
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s: WalkCatalog(%q): %s", name, tc.root, diff)
+			}
+		}
+	}
+}
+
+func TestNewFromPathCatalog(t *testing.T) {
+	d := testdirInit(t)
+	defer os.RemoveAll(d)
+
+	c := &commandSink{}
+	c.install()
+	viper.Set("catalog.remote", []*RemoteConfig{{Name: "foo", URL: "http://example.com/foo"}})
+
+	// Opening a hook clones the catalog first.
+	rc, err := GetRemoteConfig("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFromPath("foo@push"); err == nil {
+		t.Error("expected error for missing hook")
+	}
+	if !rc.isCached() {
+		t.Fatal("catalog was not cloned")
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(rc.Path(), "push.yaml"), []byte("method: POST\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	hooks, err := NewFromPath("foo@push")
+	if err != nil {
+		t.Fatalf("NewFromPath: %v", err)
+	}
+	if len(hooks) != 1 || hooks[0].Method != "POST" {
+		t.Errorf("got %+v", hooks)
+	}
+}
+
+func TestNewFromPathRevision(t *testing.T) {
+	d := testdirInit(t)
+	defer os.RemoveAll(d)
+
+	git := &fakeGit{
+		commits: map[string]MemCatalog{
+			"v1": {"push.yaml": []byte("method: POST\n")},
+		},
+		head:   "v1",
+		remote: map[string]MemCatalog{"v0.9": {"push.yaml": []byte("method: PUT\n")}},
+	}
+	c := &commandSink{output: git.output}
+	c.install()
+	viper.Set("catalog.remote", []*RemoteConfig{{Name: "foo", URL: "http://example.com/foo"}})
+	rc, err := GetRemoteConfig("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rc.Clone(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(rc.Path(), "push.yaml"), []byte("method: GET\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		in, method string
+	}{
+		{in: "foo@push", method: "GET"},
+		{in: "foo@push#v1", method: "POST"},
+		{in: "foo:v1@push", method: "POST"},
+		// Revisions missing from the cache are fetched.
+		{in: "foo@push#v0.9", method: "PUT"},
+	} {
+		hooks, err := NewFromPath(tc.in)
+		if err != nil {
+			t.Errorf("NewFromPath(%s): %v", tc.in, err)
+			continue
+		}
+		if hooks[0].Method != tc.method {
+			t.Errorf("NewFromPath(%s): got method %s, want %s", tc.in, hooks[0].Method, tc.method)
+		}
+	}
+	if git.head != "v1" {
+		t.Errorf("checkout changed to %s", git.head)
+	}
+	if _, err := NewFromPath("foo@push#nope"); err == nil {
+		t.Error("expected error for unknown revision")
+	}
+
+	listings, err := ListCatalogs("foo@#v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := listings[0].Hooks[0].Ref; got != "foo@push#v1" {
+		t.Errorf("listed %s, want foo@push#v1", got)
+	}
+}
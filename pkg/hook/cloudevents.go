@@ -0,0 +1,268 @@
+package hook
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// CloudEventsMode is a CloudEvents HTTP content mode.
+type CloudEventsMode string
+
+const (
+	// CloudEventsBinary carries event attributes in ce-* headers and the
+	// event data as the body.
+	CloudEventsBinary CloudEventsMode = "binary"
+	// CloudEventsStructured carries the whole event as a JSON body.
+	CloudEventsStructured CloudEventsMode = "structured"
+
+	// CloudEventsContentType is the content type of structured events.
+	CloudEventsContentType = "application/cloudevents+json"
+
+	// cloudEventsPrefix prefixes attribute headers in binary mode.
+	cloudEventsPrefix = "Ce-"
+)
+
+// DetectCloudEvents returns the content mode of the hook, or an empty string
+// if it isn't a CloudEvent.
+func DetectCloudEvents(h *Hook) CloudEventsMode {
+	if mt, _, err := mime.ParseMediaType(h.Headers.Get("Content-Type")); err == nil && mt == CloudEventsContentType {
+		return CloudEventsStructured
+	}
+	if cloudEventsHeader(h.Headers, "specversion") != "" {
+		return CloudEventsBinary
+	}
+	return ""
+}
+
+type cloudEventsOption struct{}
+
+// CloudEventsOption marks new hooks that are CloudEvents with their content
+// mode. The id and time attributes are removed, so that they are generated
+// again each time the hook is fired.
+func CloudEventsOption() Option {
+	return cloudEventsOption{}
+}
+
+func (cloudEventsOption) Apply(h *Hook) error {
+	h.CloudEvents = DetectCloudEvents(h)
+	switch h.CloudEvents {
+	case CloudEventsBinary:
+		h.Headers.Del(cloudEventsPrefix + "Id")
+		h.Headers.Del(cloudEventsPrefix + "Time")
+	case CloudEventsStructured:
+		var err error
+		for _, attr := range []string{"id", "time"} {
+			if h.Body, err = sjson.Delete(h.Body, attr); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// populateCloudEvent sets the id and time attributes of the event if they are
+// missing.
+func (h *Hook) populateCloudEvent() error {
+	id, err := newUUID()
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+
+	switch h.CloudEvents {
+	case CloudEventsBinary:
+		if h.Headers == nil {
+			h.Headers = make(http.Header)
+		}
+		if cloudEventsHeader(h.Headers, "id") == "" {
+			h.Headers.Set(cloudEventsPrefix+"Id", id)
+		}
+		if cloudEventsHeader(h.Headers, "time") == "" {
+			h.Headers.Set(cloudEventsPrefix+"Time", now)
+		}
+	case CloudEventsStructured:
+		if !gjson.Get(h.Body, "id").Exists() {
+			if h.Body, err = sjson.Set(h.Body, "id", id); err != nil {
+				return err
+			}
+		}
+		if !gjson.Get(h.Body, "time").Exists() {
+			if h.Body, err = sjson.Set(h.Body, "time", now); err != nil {
+				return err
+			}
+		}
+	case "":
+	default:
+		return fmt.Errorf("unknown CloudEvents mode %q", h.CloudEvents)
+	}
+	return nil
+}
+
+// ConvertCloudEvents returns a copy of the hook converted to the given content
+// mode. The hook must already be a CloudEvent in either mode.
+func ConvertCloudEvents(h *Hook, mode CloudEventsMode) (*Hook, error) {
+	from := h.CloudEvents
+	if from == "" {
+		from = DetectCloudEvents(h)
+	}
+	if from == "" {
+		return nil, errors.New("hook is not a CloudEvent")
+	}
+	if len(h.Transform) > 0 {
+		return nil, errors.New("hooks with transforms cannot be converted")
+	}
+
+	out := *h
+	out.Headers = h.Headers.Clone()
+	if out.Headers == nil {
+		out.Headers = make(http.Header)
+	}
+	out.CloudEvents = mode
+
+	if from == mode {
+		return &out, nil
+	}
+
+	switch mode {
+	case CloudEventsStructured:
+		return &out, toStructured(&out)
+	case CloudEventsBinary:
+		return &out, toBinary(&out)
+	}
+	return nil, fmt.Errorf("unknown CloudEvents mode %q", mode)
+}
+
+// cloudEventsAttribute returns the attribute of a binary mode header, or
+// false if key isn't one. Headers of hook documents aren't canonicalized, so
+// the prefix is matched regardless of case.
+func cloudEventsAttribute(key string) (string, bool) {
+	if len(key) <= len(cloudEventsPrefix) || !strings.EqualFold(key[:len(cloudEventsPrefix)], cloudEventsPrefix) {
+		return "", false
+	}
+	return strings.ToLower(key[len(cloudEventsPrefix):]), true
+}
+
+// cloudEventsHeader returns the value of the header of a binary mode
+// attribute, whatever the case of its key.
+func cloudEventsHeader(h http.Header, attr string) string {
+	for k, v := range h {
+		if a, ok := cloudEventsAttribute(k); ok && a == attr && len(v) > 0 {
+			return v[0]
+		}
+	}
+	return ""
+}
+
+// toStructured moves the ce-* headers and body into a structured event.
+func toStructured(h *Hook) error {
+	event := make(map[string]interface{})
+	for k, v := range h.Headers {
+		attr, ok := cloudEventsAttribute(k)
+		if !ok || len(v) == 0 {
+			continue
+		}
+		event[attr] = v[0]
+		delete(h.Headers, k)
+	}
+
+	ct := h.Headers.Get("Content-Type")
+	if ct != "" {
+		event["datacontenttype"] = ct
+	}
+	if h.Body != "" {
+		switch {
+		case isJSONContentType(ct):
+			if !json.Valid([]byte(h.Body)) {
+				return errors.New("body is not valid JSON")
+			}
+			event["data"] = json.RawMessage(h.Body)
+		case isTextContentType(ct):
+			event["data"] = h.Body
+		default:
+			event["data_base64"] = base64.StdEncoding.EncodeToString([]byte(h.Body))
+		}
+	}
+
+	b, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	h.Body = string(b)
+	h.Headers.Set("Content-Type", CloudEventsContentType+"; charset=utf-8")
+	return nil
+}
+
+// toBinary moves the attributes of a structured event into ce-* headers.
+func toBinary(h *Hook) error {
+	var event map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(h.Body), &event); err != nil {
+		return fmt.Errorf("invalid structured event: %v", err)
+	}
+
+	ct := "application/json"
+	if v, ok := event["datacontenttype"]; ok {
+		if err := json.Unmarshal(v, &ct); err != nil {
+			return fmt.Errorf("datacontenttype: %v", err)
+		}
+	}
+	h.Headers.Del("Content-Type")
+	h.Body = ""
+	keys := make([]string, 0, len(event))
+	for k := range event {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := event[k]
+		switch k {
+		case "data":
+			// JSON data is kept as is, anything else is carried as a string.
+			var s string
+			if !isJSONContentType(ct) && json.Unmarshal(v, &s) == nil {
+				h.Body = s
+			} else {
+				h.Body = string(v)
+			}
+			h.Headers.Set("Content-Type", ct)
+		case "data_base64":
+			var s string
+			if err := json.Unmarshal(v, &s); err != nil {
+				return fmt.Errorf("data_base64: %v", err)
+			}
+			b, err := base64.StdEncoding.DecodeString(s)
+			if err != nil {
+				return fmt.Errorf("data_base64: %v", err)
+			}
+			h.Body = string(b)
+		case "datacontenttype":
+			h.Headers.Set("Content-Type", ct)
+		default:
+			var s string
+			if json.Unmarshal(v, &s) != nil {
+				s = string(v)
+			}
+			h.Headers.Set(cloudEventsPrefix+k, s)
+		}
+	}
+	return nil
+}
+
+func isJSONContentType(ct string) bool {
+	mt, _, _ := mime.ParseMediaType(ct)
+	return mt == "application/json" || strings.HasSuffix(mt, "+json")
+}
+
+func isTextContentType(ct string) bool {
+	mt, _, _ := mime.ParseMediaType(ct)
+	return strings.HasPrefix(mt, "text/") || mt == "application/xml" || strings.HasSuffix(mt, "+xml")
+}
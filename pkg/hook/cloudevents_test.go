@@ -0,0 +1,169 @@
+package hook
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tidwall/gjson"
+)
+
+func binaryEvent() *Hook {
+	return &Hook{
+		Method: http.MethodPost,
+		Headers: http.Header{
+			"Ce-Specversion": {"1.0"},
+			"Ce-Type":        {"com.example.order.created"},
+			"Ce-Source":      {"/orders"},
+			"Ce-Id":          {"1"},
+			"Content-Type":   {"application/json"},
+		},
+		Body:        `{"order":42}`,
+		CloudEvents: CloudEventsBinary,
+	}
+}
+
+func structuredEvent() *Hook {
+	return &Hook{
+		Method:      http.MethodPost,
+		Headers:     http.Header{"Content-Type": {CloudEventsContentType + "; charset=utf-8"}},
+		Body:        `{"data":{"order":42},"datacontenttype":"application/json","id":"1","source":"/orders","specversion":"1.0","type":"com.example.order.created"}`,
+		CloudEvents: CloudEventsStructured,
+	}
+}
+
+func TestDetectCloudEvents(t *testing.T) {
+	if got := DetectCloudEvents(binaryEvent()); got != CloudEventsBinary {
+		t.Errorf("binary: got %q", got)
+	}
+	if got := DetectCloudEvents(structuredEvent()); got != CloudEventsStructured {
+		t.Errorf("structured: got %q", got)
+	}
+	if got := DetectCloudEvents(&Hook{Method: http.MethodPost}); got != "" {
+		t.Errorf("plain hook: got %q", got)
+	}
+}
+
+func TestConvertCloudEvents(t *testing.T) {
+	got, err := ConvertCloudEvents(binaryEvent(), CloudEventsStructured)
+	if err != nil {
+		t.Fatalf("to structured: %v", err)
+	}
+	if diff := cmp.Diff(structuredEvent(), got); diff != "" {
+		t.Errorf("to structured: %s", diff)
+	}
+
+	got, err = ConvertCloudEvents(structuredEvent(), CloudEventsBinary)
+	if err != nil {
+		t.Fatalf("to binary: %v", err)
+	}
+	if diff := cmp.Diff(binaryEvent(), got); diff != "" {
+		t.Errorf("to binary: %s", diff)
+	}
+
+	// Non JSON data round trips through data_base64 and text through data.
+	for _, tc := range []struct {
+		ct, body, attr string
+	}{
+		{ct: "application/octet-stream", body: "\x00\x01", attr: "data_base64"},
+		{ct: "text/plain", body: "hello", attr: "data"},
+	} {
+		h := binaryEvent()
+		h.Headers.Set("Content-Type", tc.ct)
+		h.Body = tc.body
+		s, err := ConvertCloudEvents(h, CloudEventsStructured)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.ct, err)
+		}
+		if !gjson.Get(s.Body, tc.attr).Exists() {
+			t.Errorf("%s: missing %s in %s", tc.ct, tc.attr, s.Body)
+		}
+		b, err := ConvertCloudEvents(s, CloudEventsBinary)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.ct, err)
+		}
+		if diff := cmp.Diff(h, b); diff != "" {
+			t.Errorf("%s: %s", tc.ct, diff)
+		}
+	}
+
+	// Headers of hook documents aren't canonicalized.
+	h := binaryEvent()
+	h.Headers = http.Header{
+		"ce-specversion": {"1.0"},
+		"CE-Type":        {"com.example.order.created"},
+		"ce-source":      {"/orders"},
+		"ce-id":          {"1"},
+		"Content-Type":   {"application/json"},
+	}
+	h.CloudEvents = ""
+	got, err = ConvertCloudEvents(h, CloudEventsStructured)
+	if err != nil {
+		t.Fatalf("lowercase to structured: %v", err)
+	}
+	if diff := cmp.Diff(structuredEvent(), got); diff != "" {
+		t.Errorf("lowercase to structured: %s", diff)
+	}
+
+	if _, err := ConvertCloudEvents(&Hook{Method: http.MethodPost}, CloudEventsBinary); err == nil {
+		t.Error("expected error converting a plain hook")
+	}
+}
+
+func TestCloudEventsOption(t *testing.T) {
+	h := structuredEvent()
+	h.CloudEvents = ""
+	h.Body = `{"id":"1","time":"2019-12-25T08:30:00Z","type":"t"}`
+	if err := CloudEventsOption().Apply(h); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if h.CloudEvents != CloudEventsStructured || h.Body != `{"type":"t"}` {
+		t.Errorf("got mode %q, body %s", h.CloudEvents, h.Body)
+	}
+
+	b := binaryEvent()
+	b.CloudEvents = ""
+	b.Headers.Set("Ce-Time", "2019-12-25T08:30:00Z")
+	if err := CloudEventsOption().Apply(b); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if b.CloudEvents != CloudEventsBinary || b.Headers.Get("Ce-Id") != "" || b.Headers.Get("Ce-Time") != "" {
+		t.Errorf("got mode %q, headers %v", b.CloudEvents, b.Headers)
+	}
+}
+
+func TestRenderCloudEvents(t *testing.T) {
+	b := binaryEvent()
+	b.Headers.Del("Ce-Id")
+	r, err := b.render()
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if r.Headers.Get("Ce-Id") == "" || r.Headers.Get("Ce-Time") == "" {
+		t.Errorf("binary: id and time not populated: %v", r.Headers)
+	}
+	if b.Headers.Get("Ce-Id") != "" {
+		t.Error("binary: render modified the hook")
+	}
+
+	l := binaryEvent()
+	l.Headers = http.Header{"ce-specversion": {"1.0"}, "ce-id": {"1"}}
+	if r, err = l.render(); err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if len(r.Headers) != 3 || r.Headers.Get("Ce-Id") != "" {
+		t.Errorf("binary: lowercase id replaced: %v", r.Headers)
+	}
+
+	s := structuredEvent()
+	r, err = s.render()
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if id := gjson.Get(r.Body, "id").String(); id != "1" {
+		t.Errorf("structured: existing id replaced with %s", id)
+	}
+	if !gjson.Get(r.Body, "time").Exists() {
+		t.Errorf("structured: time not populated: %s", r.Body)
+	}
+}
@@ -16,6 +16,10 @@ const (
 )
 
 var (
+	// Version is the version of hook. This is a variable so that it can be
+	// set at build time.
+	Version = "0.0.1"
+
 	// DefaultCatalog is the default catalog installed automatically for users.
 	DefaultCatalog = &RemoteConfig{
 		Name: "@",
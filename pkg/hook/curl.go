@@ -0,0 +1,496 @@
+package hook
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// formBoundary is the multipart boundary used for curl -F bodies. A fixed
+// boundary keeps imported hooks stable.
+const formBoundary = "hook-form-boundary"
+
+// curlNoArg are curl options which don't take an argument and don't affect
+// the request sent (output, TLS and connection settings).
+var curlNoArg = map[string]bool{
+	"-s": true, "--silent": true,
+	"-S": true, "--show-error": true,
+	"-v": true, "--verbose": true,
+	"-i": true, "--include": true,
+	"-k": true, "--insecure": true,
+	"-L": true, "--location": true,
+	"-f": true, "--fail": true,
+	"-N": true, "--no-buffer": true,
+	"-#": true, "--progress-bar": true,
+	"--compressed": true,
+	"--http1.1":    true,
+	"--http2":      true,
+}
+
+// curlIgnoredArg are curl options which take an argument but don't affect the
+// request sent.
+var curlIgnoredArg = map[string]bool{
+	"-o": true, "--output": true,
+	"-m": true, "--max-time": true,
+	"-w": true, "--write-out": true,
+	"-x": true, "--proxy": true,
+	"--connect-timeout": true,
+	"--retry":           true,
+	"--cacert":          true,
+	"--cert":            true,
+	"--key":             true,
+	"--resolve":         true,
+}
+
+// curlArg are curl options which take an argument and are converted into the
+// hook.
+var curlArg = map[string]string{
+	"-X": "--request", "--request": "--request",
+	"-H": "--header", "--header": "--header",
+	"-d": "--data", "--data": "--data", "--data-ascii": "--data",
+	"--data-raw":       "--data-raw",
+	"--data-binary":    "--data-binary",
+	"--data-urlencode": "--data-urlencode",
+	"--json":           "--json",
+	"-F":               "--form", "--form": "--form",
+	"-u": "--user", "--user": "--user",
+	"-A": "--user-agent", "--user-agent": "--user-agent",
+	"-e": "--referer", "--referer": "--referer",
+	"-b": "--cookie", "--cookie": "--cookie",
+	"--url": "--url",
+}
+
+// curlFlag are curl options which don't take an argument and are converted
+// into the hook.
+var curlFlag = map[string]string{
+	"-G": "--get", "--get": "--get",
+	"-I": "--head", "--head": "--head",
+}
+
+// NewFromCurl creates a new Hook from a curl command line, as commonly found
+// in provider documentation.
+func NewFromCurl(cmdline string, opts ...Option) (*Hook, error) {
+	args, err := splitShellWords(cmdline)
+	if err != nil {
+		return nil, err
+	}
+	return NewFromCurlArgs(args, opts...)
+}
+
+// curlRequest accumulates the parts of a parsed curl command.
+type curlRequest struct {
+	method  string
+	rawURL  string
+	headers http.Header
+	data    []string
+	form    []string
+	get     bool
+	head    bool
+	json    bool
+}
+
+// NewFromCurlArgs creates a new Hook from already split curl arguments. The
+// leading "curl" is optional.
+func NewFromCurlArgs(args []string, opts ...Option) (*Hook, error) {
+	// Copy args, since combined short options are expanded in place.
+	args = append([]string(nil), args...)
+	if len(args) > 0 && filepath.Base(args[0]) == "curl" {
+		args = args[1:]
+	}
+
+	c := &curlRequest{headers: make(http.Header)}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			if c.rawURL != "" {
+				return nil, fmt.Errorf("multiple URLs are not supported: %s, %s", c.rawURL, arg)
+			}
+			c.rawURL = arg
+			continue
+		}
+
+		// Expand combined short options, e.g. -sSL, -XPOST or -sXPOST.
+		if !strings.HasPrefix(arg, "--") && len(arg) > 2 {
+			args = append(args[:i], append(expandCurlShort(arg), args[i+1:]...)...)
+			arg = args[i]
+		}
+
+		switch {
+		case curlNoArg[arg]:
+			continue
+		case curlFlag[arg] != "":
+			c.flag(curlFlag[arg])
+			continue
+		}
+
+		name, ok := curlArg[arg]
+		if !ok && !curlIgnoredArg[arg] {
+			return nil, fmt.Errorf("unsupported curl option %s", arg)
+		}
+		if i+1 >= len(args) {
+			return nil, fmt.Errorf("curl option %s requires an argument", arg)
+		}
+		i++
+		if !ok {
+			continue
+		}
+		if err := c.option(name, args[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	h, err := c.hook()
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range opts {
+		if err := o.Apply(h); err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+// expandCurlShort splits combined short options into separate arguments. An
+// option which takes an argument consumes the rest of the combination as its
+// value, e.g. -sXPOST is expanded into -s -X POST.
+func expandCurlShort(arg string) []string {
+	var expanded []string
+	for j := 1; j < len(arg); j++ {
+		opt := "-" + arg[j:j+1]
+		expanded = append(expanded, opt)
+		if _, ok := curlArg[opt]; (ok || curlIgnoredArg[opt]) && j+1 < len(arg) {
+			return append(expanded, arg[j+1:])
+		}
+	}
+	return expanded
+}
+
+func (c *curlRequest) flag(name string) {
+	switch name {
+	case "--get":
+		c.get = true
+	case "--head":
+		c.head = true
+	}
+}
+
+func (c *curlRequest) option(name, value string) error {
+	switch name {
+	case "--request":
+		c.method = strings.ToUpper(value)
+	case "--header":
+		kv := strings.SplitN(value, ":", 2)
+		if len(kv) != 2 {
+			// curl uses "Name;" to send an empty header.
+			if strings.HasSuffix(value, ";") {
+				c.headers.Add(strings.TrimSuffix(value, ";"), "")
+				return nil
+			}
+			return fmt.Errorf("invalid header %q", value)
+		}
+		if v := strings.TrimSpace(kv[1]); v != "" {
+			c.headers.Add(strings.TrimSpace(kv[0]), v)
+		} else {
+			// "Name:" removes a header curl would otherwise send.
+			c.headers.Del(strings.TrimSpace(kv[0]))
+		}
+	case "--data":
+		d, err := readCurlData(value, true)
+		if err != nil {
+			return err
+		}
+		c.data = append(c.data, d)
+	case "--data-raw":
+		c.data = append(c.data, value)
+	case "--data-binary":
+		d, err := readCurlData(value, false)
+		if err != nil {
+			return err
+		}
+		c.data = append(c.data, d)
+	case "--data-urlencode":
+		d, err := urlencodeCurlData(value)
+		if err != nil {
+			return err
+		}
+		c.data = append(c.data, d)
+	case "--json":
+		d, err := readCurlData(value, false)
+		if err != nil {
+			return err
+		}
+		c.json = true
+		c.data = append(c.data, d)
+	case "--form":
+		c.form = append(c.form, value)
+	case "--user":
+		c.headers.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(value)))
+	case "--user-agent":
+		c.headers.Set("User-Agent", value)
+	case "--referer":
+		c.headers.Set("Referer", value)
+	case "--cookie":
+		if !strings.Contains(value, "=") {
+			return errors.New("reading cookies from a file is not supported")
+		}
+		c.headers.Add("Cookie", value)
+	case "--url":
+		if c.rawURL != "" {
+			return fmt.Errorf("multiple URLs are not supported: %s, %s", c.rawURL, value)
+		}
+		c.rawURL = value
+	}
+	return nil
+}
+
+// readCurlData returns the value of a data option, reading the named file if
+// the value starts with @. If strip is set, newlines are removed from file
+// contents as curl does for -d.
+func readCurlData(value string, strip bool) (string, error) {
+	if !strings.HasPrefix(value, "@") {
+		return value, nil
+	}
+	b, err := readCurlFile(value[1:])
+	if err != nil {
+		return "", err
+	}
+	s := string(b)
+	if strip {
+		s = strings.NewReplacer("\r", "", "\n", "").Replace(s)
+	}
+	return s, nil
+}
+
+func readCurlFile(path string) ([]byte, error) {
+	if path == "-" {
+		return nil, errors.New("reading curl data from stdin is not supported")
+	}
+	return ioutil.ReadFile(path)
+}
+
+// urlencodeCurlData implements the --data-urlencode formats: content,
+// =content, name=content, @file and name@file.
+func urlencodeCurlData(value string) (string, error) {
+	name, content := "", value
+	if i := strings.IndexAny(value, "=@"); i >= 0 {
+		name, content = value[:i], value[i+1:]
+		if value[i] == '@' {
+			b, err := readCurlFile(content)
+			if err != nil {
+				return "", err
+			}
+			content = string(b)
+		}
+	}
+	if name == "" {
+		return url.QueryEscape(content), nil
+	}
+	return name + "=" + url.QueryEscape(content), nil
+}
+
+// hook converts the parsed curl command into a Hook.
+func (c *curlRequest) hook() (*Hook, error) {
+	if c.rawURL == "" {
+		return nil, errors.New("no URL found in curl command")
+	}
+	if len(c.data) > 0 && len(c.form) > 0 {
+		return nil, errors.New("curl options --data and --form cannot be combined")
+	}
+
+	u, err := url.Parse(c.rawURL)
+	if err != nil {
+		return nil, err
+	}
+	params := u.Query()
+
+	h := &Hook{
+		Method:  http.MethodGet,
+		Headers: c.headers,
+	}
+	if c.head {
+		h.Method = http.MethodHead
+	}
+
+	switch {
+	case len(c.data) > 0 && c.get:
+		q, err := url.ParseQuery(strings.Join(c.data, "&"))
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range q {
+			params[k] = append(params[k], v...)
+		}
+	case len(c.data) > 0:
+		h.Method = http.MethodPost
+		if c.json {
+			h.Body = strings.Join(c.data, "")
+			setDefaultHeader(h.Headers, "Content-Type", "application/json")
+			setDefaultHeader(h.Headers, "Accept", "application/json")
+		} else {
+			h.Body = strings.Join(c.data, "&")
+			setDefaultHeader(h.Headers, "Content-Type", "application/x-www-form-urlencoded")
+		}
+	case len(c.form) > 0:
+		h.Method = http.MethodPost
+		body, err := curlForm(c.form)
+		if err != nil {
+			return nil, err
+		}
+		h.Body = body
+		h.Headers.Set("Content-Type", "multipart/form-data; boundary="+formBoundary)
+	}
+
+	if c.method != "" {
+		h.Method = c.method
+	}
+	if len(params) > 0 {
+		h.Params = params
+	}
+	if len(h.Headers) == 0 {
+		h.Headers = nil
+	}
+	return h, nil
+}
+
+func setDefaultHeader(h http.Header, key, value string) {
+	if h.Get(key) == "" {
+		h.Set(key, value)
+	}
+}
+
+// curlForm builds a multipart body from curl -F values of the form
+// name=value, name=@file (file upload) and name=<file (file contents).
+func curlForm(values []string) (string, error) {
+	buf := new(bytes.Buffer)
+	w := multipart.NewWriter(buf)
+	if err := w.SetBoundary(formBoundary); err != nil {
+		return "", err
+	}
+	for _, v := range values {
+		kv := strings.SplitN(v, "=", 2)
+		if len(kv) != 2 {
+			return "", fmt.Errorf("invalid form value %q", v)
+		}
+		name, value := kv[0], kv[1]
+		switch {
+		case strings.HasPrefix(value, "@"):
+			path := strings.SplitN(value[1:], ";", 2)[0]
+			b, err := readCurlFile(path)
+			if err != nil {
+				return "", err
+			}
+			part, err := w.CreateFormFile(name, filepath.Base(path))
+			if err != nil {
+				return "", err
+			}
+			part.Write(b)
+		case strings.HasPrefix(value, "<"):
+			b, err := readCurlFile(value[1:])
+			if err != nil {
+				return "", err
+			}
+			if err := w.WriteField(name, string(b)); err != nil {
+				return "", err
+			}
+		default:
+			if err := w.WriteField(name, value); err != nil {
+				return "", err
+			}
+		}
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// splitShellWords splits a command line into words following POSIX shell
+// quoting rules, including line continuations and $'...' strings as produced
+// by browser "copy as cURL" features.
+func splitShellWords(s string) ([]string, error) {
+	var (
+		words   []string
+		word    strings.Builder
+		inWord  bool
+		runes   = []rune(s)
+		escapes = map[rune]rune{'n': '\n', 't': '\t', 'r': '\r', '\\': '\\', '\'': '\'', '"': '"'}
+	)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\\':
+			if i+1 >= len(runes) {
+				return nil, errors.New("trailing backslash")
+			}
+			i++
+			if runes[i] == '\n' {
+				// Line continuation.
+				continue
+			}
+			word.WriteRune(runes[i])
+			inWord = true
+		case r == '\'':
+			i++
+			for ; i < len(runes) && runes[i] != '\''; i++ {
+				word.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, errors.New("unterminated single quote")
+			}
+			inWord = true
+		case r == '$' && i+1 < len(runes) && runes[i+1] == '\'':
+			i += 2
+			for ; i < len(runes) && runes[i] != '\''; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+					if e, ok := escapes[runes[i]]; ok {
+						word.WriteRune(e)
+						continue
+					}
+					word.WriteRune('\\')
+				}
+				word.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, errors.New("unterminated $' quote")
+			}
+			inWord = true
+		case r == '"':
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) && strings.ContainsRune("$`\"\\\n", runes[i+1]) {
+					i++
+					if runes[i] == '\n' {
+						continue
+					}
+				}
+				word.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, errors.New("unterminated double quote")
+			}
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
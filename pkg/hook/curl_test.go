@@ -0,0 +1,213 @@
+package hook
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSplitShellWords(t *testing.T) {
+	testcases := []struct {
+		in   string
+		want []string
+	}{
+		{
+			in:   `curl -X POST http://localhost`,
+			want: []string{"curl", "-X", "POST", "http://localhost"},
+		},
+		{
+			in:   `curl -H 'Content-Type: application/json' -d "{\"a\": \"$b\"}"`,
+			want: []string{"curl", "-H", "Content-Type: application/json", "-d", `{"a": "$b"}`},
+		},
+		{
+			in:   "curl \\\n  -d 'it'\\''s' \\\n  http://localhost",
+			want: []string{"curl", "-d", "it's", "http://localhost"},
+		},
+		{
+			in:   `curl --data-raw $'{"a":\n"it\'s"}'`,
+			want: []string{"curl", "--data-raw", "{\"a\":\n\"it's\"}"},
+		},
+	}
+	for _, tc := range testcases {
+		got, err := splitShellWords(tc.in)
+		if err != nil {
+			t.Errorf("splitShellWords(%s): %v", tc.in, err)
+			continue
+		}
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("splitShellWords(%s): %s", tc.in, diff)
+		}
+	}
+
+	for _, in := range []string{`'unterminated`, `"unterminated`, `trailing\`} {
+		if _, err := splitShellWords(in); err == nil {
+			t.Errorf("splitShellWords(%s): expected error", in)
+		}
+	}
+}
+
+func TestNewFromCurl(t *testing.T) {
+	f := testfile(t, "data.json")
+	defer deletefile(t, f)
+	fmt.Fprint(f, "{\n  \"foo\": \"bar\"\n}\n")
+	f.Close()
+
+	testcases := []struct {
+		name string
+		in   string
+		want *Hook
+	}{
+		{
+			name: "get",
+			in:   "curl 'http://localhost/hook?a=b&a=c'",
+			want: &Hook{
+				Method: http.MethodGet,
+				Params: url.Values{"a": {"b", "c"}},
+			},
+		},
+		{
+			name: "json data",
+			in:   `curl -sSL -XPOST http://localhost -H 'Content-Type: application/json' -H 'X-GitHub-Event: ping' -d '{"zen":"hi"}'`,
+			want: &Hook{
+				Method: http.MethodPost,
+				Headers: http.Header{
+					"Content-Type":   {"application/json"},
+					"X-Github-Event": {"ping"},
+				},
+				Body: `{"zen":"hi"}`,
+			},
+		},
+		{
+			name: "form data",
+			in:   `curl http://localhost -d a=b -d c=d --data-urlencode 'msg=hello world' -X PUT`,
+			want: &Hook{
+				Method:  http.MethodPut,
+				Headers: http.Header{"Content-Type": {"application/x-www-form-urlencoded"}},
+				Body:    "a=b&c=d&msg=hello+world",
+			},
+		},
+		{
+			name: "data from file strips newlines",
+			in:   fmt.Sprintf("curl http://localhost -d @%s", f.Name()),
+			want: &Hook{
+				Method:  http.MethodPost,
+				Headers: http.Header{"Content-Type": {"application/x-www-form-urlencoded"}},
+				Body:    `{  "foo": "bar"}`,
+			},
+		},
+		{
+			name: "binary data from file",
+			in:   fmt.Sprintf("curl http://localhost --data-binary @%s -H 'Content-Type: application/json'", f.Name()),
+			want: &Hook{
+				Method:  http.MethodPost,
+				Headers: http.Header{"Content-Type": {"application/json"}},
+				Body:    "{\n  \"foo\": \"bar\"\n}\n",
+			},
+		},
+		{
+			name: "json option",
+			in:   `curl --json '{"a":1}' --url http://localhost`,
+			want: &Hook{
+				Method: http.MethodPost,
+				Headers: http.Header{
+					"Content-Type": {"application/json"},
+					"Accept":       {"application/json"},
+				},
+				Body: `{"a":1}`,
+			},
+		},
+		{
+			name: "get with data",
+			in:   `curl -G http://localhost?a=b -d c=d`,
+			want: &Hook{
+				Method: http.MethodGet,
+				Params: url.Values{"a": {"b"}, "c": {"d"}},
+			},
+		},
+		{
+			name: "combined options with method",
+			in:   `curl -sXPOST http://localhost -sSd a=b`,
+			want: &Hook{
+				Method:  http.MethodPost,
+				Headers: http.Header{"Content-Type": {"application/x-www-form-urlencoded"}},
+				Body:    "a=b",
+			},
+		},
+		{
+			name: "combined options with separate value",
+			in:   `curl -sH 'X-GitHub-Event: ping' -sLXPUT http://localhost`,
+			want: &Hook{
+				Method:  http.MethodPut,
+				Headers: http.Header{"X-Github-Event": {"ping"}},
+			},
+		},
+		{
+			name: "user and agent",
+			in:   `curl -u user:pass -A hook/1.0 -o /dev/null http://localhost`,
+			want: &Hook{
+				Method: http.MethodGet,
+				Headers: http.Header{
+					"Authorization": {"Basic dXNlcjpwYXNz"},
+					"User-Agent":    {"hook/1.0"},
+				},
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := NewFromCurl(tc.in)
+			if err != nil {
+				t.Fatalf("NewFromCurl: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestNewFromCurlForm(t *testing.T) {
+	f := testfile(t, "upload.txt")
+	defer deletefile(t, f)
+	fmt.Fprint(f, "file contents")
+	f.Close()
+
+	h, err := NewFromCurlArgs([]string{"curl", "-F", "name=hook", "-F", "upload=@" + f.Name(), "http://localhost"})
+	if err != nil {
+		t.Fatalf("NewFromCurlArgs: %v", err)
+	}
+	if h.Method != http.MethodPost {
+		t.Errorf("got method %s, want POST", h.Method)
+	}
+	if ct := h.Headers.Get("Content-Type"); ct != "multipart/form-data; boundary="+formBoundary {
+		t.Errorf("got Content-Type %s", ct)
+	}
+	for _, want := range []string{
+		`Content-Disposition: form-data; name="name"`,
+		"hook",
+		`Content-Disposition: form-data; name="upload"; filename=`,
+		"file contents",
+	} {
+		if !strings.Contains(h.Body, want) {
+			t.Errorf("body missing %q:\n%s", want, h.Body)
+		}
+	}
+}
+
+func TestNewFromCurlErrors(t *testing.T) {
+	for _, in := range []string{
+		"curl -X POST",
+		"curl http://a http://b",
+		"curl --frobnicate http://localhost",
+		"curl http://localhost -H",
+		"curl http://localhost -d a=b -F c=d",
+	} {
+		if _, err := NewFromCurl(in); err == nil {
+			t.Errorf("NewFromCurl(%s): expected error", in)
+		}
+	}
+}
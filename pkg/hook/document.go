@@ -0,0 +1,292 @@
+package hook
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// APIVersion is the current version of hook documents.
+	APIVersion = "hook/v1"
+	// KindHook is the kind of hook documents.
+	KindHook = "Hook"
+)
+
+// header identifies the version of a document. Field descriptions are used
+// in the JSON Schema, see Schema.
+type header struct {
+	APIVersion string `yaml:"apiVersion" description:"Version of the hook document format."`
+	Kind       string `yaml:"kind" description:"Kind of document."`
+}
+
+// hookFields are the fields of a hook document.
+type hookFields struct {
+	Name        string `yaml:"name,omitempty" description:"Human readable name of the hook."`
+	Description string `yaml:"description,omitempty" description:"What the hook is and when it is sent."`
+
+	Method  string      `yaml:"method" description:"HTTP method of the request."`
+	Headers http.Header `yaml:"headers,omitempty" description:"Request headers. Values are templates if the hook is templated."`
+	Body    string      `yaml:"body,omitempty" description:"Request body. It is a template if the hook is templated."`
+	Params  url.Values  `yaml:"params,omitempty" description:"Query parameters. Values are templates if the hook is templated."`
+
+	Transform   map[TransformStrategy][]string `yaml:"transform,omitempty" description:"JSON paths of the body to encode before firing, by transform."`
+	Template    bool                           `yaml:"template,omitempty" description:"Render the body, header values and param values as templates. Hooks with vars are always rendered."`
+	Vars        map[string]string              `yaml:"vars,omitempty" description:"Template variables."`
+	CloudEvents CloudEventsMode                `yaml:"cloudevents,omitempty" description:"CloudEvents content mode. The id and time attributes are populated when fired."`
+	Envelope    *Envelope                      `yaml:"envelope,omitempty" description:"Cloud provider envelope to wrap the body in when fired."`
+	Signing     *Signing                       `yaml:"signing,omitempty" description:"HMAC signature of the body to send when fired."`
+	ReceivedAt  time.Time                      `yaml:"receivedAt,omitempty" description:"When the hook was recorded."`
+}
+
+func newHookFields(h *Hook) hookFields {
+	return hookFields{
+		Name:        h.Name,
+		Description: h.Description,
+		Method:      h.Method,
+		Headers:     h.Headers,
+		Body:        h.Body,
+		Params:      h.Params,
+		Transform:   h.Transform,
+		Template:    h.Template,
+		Vars:        h.Vars,
+		CloudEvents: h.CloudEvents,
+		Envelope:    h.Envelope,
+		Signing:     h.Signing,
+		ReceivedAt:  h.ReceivedAt,
+	}
+}
+
+func (f *hookFields) hook() *Hook {
+	return &Hook{
+		Name:        f.Name,
+		Description: f.Description,
+		Method:      f.Method,
+		Headers:     f.Headers,
+		Body:        f.Body,
+		Params:      f.Params,
+		Transform:   f.Transform,
+		Template:    f.Template,
+		Vars:        f.Vars,
+		CloudEvents: f.CloudEvents,
+		Envelope:    f.Envelope,
+		Signing:     f.Signing,
+		ReceivedAt:  f.ReceivedAt,
+	}
+}
+
+// hookV1 is the hook/v1 document.
+type hookV1 struct {
+	header     `yaml:",inline"`
+	hookFields `yaml:",inline"`
+}
+
+// hookLegacy is a document without an apiVersion, written before documents
+// were versioned.
+type hookLegacy struct {
+	hookFields `yaml:",inline"`
+}
+
+// document is a single YAML document of a multidoc file.
+type document struct {
+	// Line is the line of the file the document starts on, starting at 1.
+	Line int
+	Data []byte
+}
+
+// splitDocuments splits a multidoc YAML file on "---" lines. Unlike the yaml
+// decoder, this keeps track of where each document starts so that errors
+// can point at lines of the file.
+func splitDocuments(b []byte) []*document {
+	var docs []*document
+	cur := &document{Line: 1}
+	line := 0
+	s := bufio.NewScanner(bytes.NewReader(b))
+	s.Buffer(nil, len(b)+1)
+	for s.Scan() {
+		line++
+		t := s.Text()
+		if t == "---" || strings.HasPrefix(t, "--- ") {
+			docs = append(docs, cur)
+			cur = &document{Line: line + 1}
+			continue
+		}
+		cur.Data = append(cur.Data, t...)
+		cur.Data = append(cur.Data, '\n')
+	}
+	return append(docs, cur)
+}
+
+// empty reports whether the document contains nothing but whitespace and
+// comments.
+func (d *document) empty() bool {
+	var v interface{}
+	return yaml.Unmarshal(d.Data, &v) == nil && v == nil
+}
+
+// version returns the version header of the document.
+func (d *document) version() (*header, error) {
+	h := new(header)
+	if err := yaml.Unmarshal(d.Data, h); err != nil {
+		return nil, d.errorf(err)
+	}
+	return h, nil
+}
+
+// decode strictly decodes the document according to its version.
+func (d *document) decode() (*Hook, error) {
+	v, err := d.version()
+	if err != nil {
+		return nil, err
+	}
+
+	switch v.APIVersion {
+	case "":
+		if v.Kind != "" {
+			return nil, &DocumentError{Line: d.Line, Msg: "kind set without apiVersion"}
+		}
+		doc := new(hookLegacy)
+		if err := yaml.UnmarshalStrict(d.Data, doc); err != nil {
+			return nil, d.errorf(err)
+		}
+		return doc.hook(), nil
+	case APIVersion:
+		if v.Kind != KindHook {
+			return nil, &DocumentError{Line: d.Line, Msg: fmt.Sprintf("unsupported kind %q, expected %s", v.Kind, KindHook)}
+		}
+		doc := new(hookV1)
+		if err := yaml.UnmarshalStrict(d.Data, doc); err != nil {
+			return nil, d.errorf(err)
+		}
+		return doc.hook(), nil
+	default:
+		return nil, &DocumentError{Line: d.Line, Msg: fmt.Sprintf("unsupported apiVersion %q, expected %s", v.APIVersion, APIVersion)}
+	}
+}
+
+// DocumentError is an error in a hook document.
+type DocumentError struct {
+	// Line is the line of the file the error is on, starting at 1.
+	Line int
+	Msg  string
+}
+
+func (e *DocumentError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// DocumentErrors are all errors found in a document.
+type DocumentErrors []*DocumentError
+
+func (e DocumentErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+var (
+	yamlLine       = regexp.MustCompile(`^(?:yaml: )?line (\d+): `)
+	yamlUnknownKey = regexp.MustCompile(`^field (\S+) not found in type \S+$`)
+)
+
+// errorf converts errors of the yaml package into DocumentErrors with lines
+// relative to the start of the file.
+func (d *document) errorf(err error) error {
+	var msgs []string
+	if te, ok := err.(*yaml.TypeError); ok {
+		msgs = te.Errors
+	} else {
+		msgs = []string{err.Error()}
+	}
+
+	errs := make(DocumentErrors, 0, len(msgs))
+	for _, msg := range msgs {
+		e := &DocumentError{Line: d.Line, Msg: strings.TrimPrefix(msg, "yaml: ")}
+		if m := yamlLine.FindStringSubmatch(msg); m != nil {
+			n, _ := strconv.Atoi(m[1])
+			e.Line = d.Line + n - 1
+			e.Msg = msg[len(m[0]):]
+		}
+		e.Msg = yamlUnknownKey.ReplaceAllString(e.Msg, "unknown field $1")
+		errs = append(errs, e)
+	}
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return errs
+}
+
+// Dump serializes the hook as a current version document, starting with a
+// modeline so that editors validate it against the published schema.
+func (h *Hook) Dump() ([]byte, error) {
+	b, err := h.dump()
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(SchemaModeline), b...), nil
+}
+
+// dump serializes the hook without a modeline. JSON bodies are indented,
+// which also gets around line length restrictions of the yaml package in most
+// cases.
+func (h *Hook) dump() ([]byte, error) {
+	doc := &hookV1{
+		header:     header{APIVersion: APIVersion, Kind: KindHook},
+		hookFields: newHookFields(h),
+	}
+	if h.Headers.Get("Content-Type") == "application/json" {
+		buf := new(bytes.Buffer)
+		if err := json.Indent(buf, []byte(h.Body), "", "  "); err != nil {
+			return nil, fmt.Errorf("error indenting payload: %v", err)
+		}
+		doc.Body = buf.String()
+	}
+	return yaml.Marshal(doc)
+}
+
+// Migrate rewrites the hook documents read from r to the current version.
+// changed is false if all documents already are, in which case out is the
+// input unchanged. Comments and formatting are not preserved when migrating.
+func Migrate(r io.Reader) (out []byte, changed bool, err error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var hooks []*Hook
+	for _, d := range splitDocuments(b) {
+		if d.empty() {
+			continue
+		}
+		v, err := d.version()
+		if err != nil {
+			return nil, false, err
+		}
+		if v.APIVersion != APIVersion {
+			changed = true
+		}
+		h, err := d.decode()
+		if err != nil {
+			return nil, false, err
+		}
+		hooks = append(hooks, h)
+	}
+	if !changed {
+		return b, false, nil
+	}
+	out, err = DumpAll(hooks)
+	return out, true, err
+}
@@ -0,0 +1,119 @@
+package hook
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewVersions(t *testing.T) {
+	in := `# legacy document
+method: GET
+---
+apiVersion: hook/v1
+kind: Hook
+name: push
+method: POST
+body: ping
+`
+	got, err := New(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	want := []*Hook{
+		{Method: http.MethodGet},
+		{Name: "push", Method: http.MethodPost, Body: "ping"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	testcases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "unknown field",
+			in:   "method: GET\n---\napiVersion: hook/v1\nkind: Hook\nmethod: POST\nheader:\n  a: [b]\n",
+			want: "line 6: unknown field header",
+		},
+		{
+			name: "unsupported version",
+			in:   "method: GET\n---\n\napiVersion: hook/v9\nkind: Hook\n",
+			want: `line 3: unsupported apiVersion "hook/v9", expected hook/v1`,
+		},
+		{
+			name: "wrong kind",
+			in:   "apiVersion: hook/v1\nkind: Catalog\n",
+			want: `line 1: unsupported kind "Catalog", expected Hook`,
+		},
+		{
+			name: "syntax",
+			in:   "method: GET\n---\nmethod: POST\nbody: [\n",
+			want: "line 4: did not find expected node content",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := New(strings.NewReader(tc.in))
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if got := err.Error(); got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	in := `method: POST
+headers:
+  Content-Type:
+  - application/json
+body: '{"a": 1}'
+---
+apiVersion: hook/v1
+kind: Hook
+method: GET
+`
+	got, changed, err := Migrate(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if !changed {
+		t.Error("expected changes")
+	}
+	want := SchemaModeline + `apiVersion: hook/v1
+kind: Hook
+method: POST
+headers:
+  Content-Type:
+  - application/json
+body: |-
+  {
+    "a": 1
+  }
+---
+apiVersion: hook/v1
+kind: Hook
+method: GET
+`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Error(diff)
+	}
+
+	// Current documents are left alone.
+	again, changed, err := Migrate(strings.NewReader(want))
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if changed || string(again) != want {
+		t.Errorf("migrating current documents: changed %v, got %s", changed, again)
+	}
+}
@@ -0,0 +1,417 @@
+package hook
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	// Envelopes are the supported cloud envelopes.
+	Envelopes = map[string]Enveloper{
+		"sns":         SNSEnvelope{},
+		"pubsub-push": PubSubPushEnvelope{},
+		"eventbridge": EventBridgeEnvelope{},
+	}
+)
+
+// Envelope declares that the hook body is wrapped in a cloud provider's
+// envelope when fired. It can be written as just the type:
+//
+//	envelope: pubsub-push
+//
+// or with attributes, whose meaning depends on the type:
+//
+//	envelope:
+//	  type: sns
+//	  attributes:
+//	    topicArn: arn:aws:sns:us-east-1:123456789012:orders
+type Envelope struct {
+	Type       string            `yaml:"type" description:"Envelope type."`
+	Attributes map[string]string `yaml:"attributes,omitempty" description:"Envelope specific attributes."`
+}
+
+// UnmarshalYAML accepts both the scalar and the mapping forms.
+func (e *Envelope) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if unmarshal(&s) == nil {
+		*e = Envelope{Type: s}
+		return nil
+	}
+	type envelope Envelope
+	return unmarshal((*envelope)(e))
+}
+
+// MarshalYAML uses the scalar form if there are no attributes.
+func (e *Envelope) MarshalYAML() (interface{}, error) {
+	if len(e.Attributes) == 0 {
+		return e.Type, nil
+	}
+	type envelope Envelope
+	return (*envelope)(e), nil
+}
+
+// Enveloper wraps and unwraps the payloads of a cloud provider.
+type Enveloper interface {
+	// Wrap returns the body and headers of a delivery of body.
+	Wrap(body string, attrs map[string]string) (string, http.Header, error)
+	// Unwrap returns the inner body and attributes of a delivery. ok is false
+	// if the delivery isn't wrapped in this envelope.
+	Unwrap(body string, headers http.Header) (inner string, attrs map[string]string, ok bool, err error)
+}
+
+// GetEnvelope returns the envelope of the given type.
+func GetEnvelope(name string) (Enveloper, error) {
+	e, ok := Envelopes[name]
+	if !ok {
+		names := make([]string, 0, len(Envelopes))
+		for n := range Envelopes {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown envelope %q, expected one of: %s", name, strings.Join(names, ", "))
+	}
+	return e, nil
+}
+
+// wrap returns the body and headers to fire, wrapped in the hook's envelope
+// if it has one.
+func (h *Hook) wrap(body string) (string, http.Header, error) {
+	headers := h.Headers.Clone()
+	if h.Envelope == nil {
+		return body, headers, nil
+	}
+	e, err := GetEnvelope(h.Envelope.Type)
+	if err != nil {
+		return "", nil, err
+	}
+	body, wrapped, err := e.Wrap(body, h.Envelope.Attributes)
+	if err != nil {
+		return "", nil, fmt.Errorf("envelope %s: %v", h.Envelope.Type, err)
+	}
+	if headers == nil {
+		headers = make(http.Header)
+	}
+	for k, v := range wrapped {
+		headers[k] = v
+	}
+	return body, headers, nil
+}
+
+type unwrapOption struct{}
+
+// UnwrapOption unwraps new hooks delivered in any of the known envelopes. The
+// inner payload becomes the body and the envelope is recorded on the hook so
+// it is wrapped again when fired.
+func UnwrapOption() Option {
+	return unwrapOption{}
+}
+
+func (unwrapOption) Apply(h *Hook) error {
+	names := make([]string, 0, len(Envelopes))
+	for n := range Envelopes {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		inner, attrs, ok, err := Envelopes[name].Unwrap(h.Body, h.Headers)
+		if err != nil {
+			return fmt.Errorf("envelope %s: %v", name, err)
+		}
+		if !ok {
+			continue
+		}
+		h.Body = inner
+		h.Envelope = &Envelope{Type: name, Attributes: attrs}
+
+		// Headers of the envelope (e.g. SNS's x-amz-sns-*) are regenerated
+		// when wrapping.
+		for k := range h.Headers {
+			if strings.HasPrefix(k, "X-Amz-") || k == "Content-Length" {
+				delete(h.Headers, k)
+			}
+		}
+		if h.Headers == nil {
+			h.Headers = make(http.Header)
+		}
+		if json.Valid([]byte(inner)) {
+			h.Headers.Set("Content-Type", "application/json")
+		} else {
+			h.Headers.Del("Content-Type")
+		}
+		return nil
+	}
+	return nil
+}
+
+// envelopeID returns a new message ID.
+func envelopeID() string {
+	id, err := newUUID()
+	if err != nil {
+		return "00000000-0000-4000-8000-000000000000"
+	}
+	return id
+}
+
+func envelopeTime() string {
+	return time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+// copyAttrs returns attrs without the given keys.
+func copyAttrs(attrs map[string]string, skip ...string) map[string]string {
+	out := make(map[string]string)
+	for k, v := range attrs {
+		out[k] = v
+	}
+	for _, k := range skip {
+		delete(out, k)
+	}
+	return out
+}
+
+// SNS message types.
+const (
+	SNSNotification             = "Notification"
+	SNSSubscriptionConfirmation = "SubscriptionConfirmation"
+)
+
+// snsMessage is an SNS HTTP(S) delivery.
+type snsMessage struct {
+	Type             string `json:"Type"`
+	MessageID        string `json:"MessageId"`
+	Token            string `json:"Token,omitempty"`
+	TopicArn         string `json:"TopicArn"`
+	Subject          string `json:"Subject,omitempty"`
+	Message          string `json:"Message"`
+	SubscribeURL     string `json:"SubscribeURL,omitempty"`
+	Timestamp        string `json:"Timestamp"`
+	SignatureVersion string `json:"SignatureVersion"`
+	Signature        string `json:"Signature"`
+	SigningCertURL   string `json:"SigningCertURL"`
+	UnsubscribeURL   string `json:"UnsubscribeURL,omitempty"`
+}
+
+// SNSEnvelope wraps bodies in AWS SNS HTTP notifications. The attributes
+// topicArn and subject set the corresponding message fields. Messages are not
+// signed.
+type SNSEnvelope struct{}
+
+// Wrap returns an SNS notification with body as the message.
+func (SNSEnvelope) Wrap(body string, attrs map[string]string) (string, http.Header, error) {
+	m := &snsMessage{
+		Type:             SNSNotification,
+		MessageID:        envelopeID(),
+		TopicArn:         attrs["topicArn"],
+		Subject:          attrs["subject"],
+		Message:          body,
+		Timestamp:        envelopeTime(),
+		SignatureVersion: "1",
+	}
+	if m.TopicArn == "" {
+		m.TopicArn = "arn:aws:sns:us-east-1:123456789012:" + AppName
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return "", nil, err
+	}
+	return string(b), http.Header{
+		"Content-Type":           {"text/plain; charset=UTF-8"},
+		"X-Amz-Sns-Message-Type": {m.Type},
+		"X-Amz-Sns-Message-Id":   {m.MessageID},
+		"X-Amz-Sns-Topic-Arn":    {m.TopicArn},
+	}, nil
+}
+
+// Unwrap returns the message of an SNS notification.
+func (SNSEnvelope) Unwrap(body string, headers http.Header) (string, map[string]string, bool, error) {
+	m, ok := parseSNS(body, headers)
+	if !ok || m.Type != SNSNotification {
+		return "", nil, false, nil
+	}
+	attrs := map[string]string{"topicArn": m.TopicArn}
+	if m.Subject != "" {
+		attrs["subject"] = m.Subject
+	}
+	return m.Message, attrs, true, nil
+}
+
+func parseSNS(body string, headers http.Header) (*snsMessage, bool) {
+	m := new(snsMessage)
+	if json.Unmarshal([]byte(body), m) != nil || m.Type == "" || m.TopicArn == "" {
+		return nil, false
+	}
+	if t := headers.Get("X-Amz-Sns-Message-Type"); t != "" && t != m.Type {
+		return nil, false
+	}
+	return m, true
+}
+
+// SNSSubscribeURL returns the URL to visit to confirm an SNS subscription, if
+// the hook is an SNS subscription confirmation.
+func SNSSubscribeURL(h *Hook) (string, bool) {
+	m, ok := parseSNS(h.Body, h.Headers)
+	if !ok || m.Type != SNSSubscriptionConfirmation || m.SubscribeURL == "" {
+		return "", false
+	}
+	return m.SubscribeURL, true
+}
+
+// snsHost matches the hosts of SNS endpoints, which SubscribeURLs point to.
+var snsHost = regexp.MustCompile(`^sns\.[a-z0-9-]+\.amazonaws\.com(\.cn)?$`)
+
+// ConfirmSNSSubscription confirms an SNS subscription by visiting its
+// SubscribeURL. Only https URLs of SNS endpoints are visited, as anyone can
+// send a confirmation with any URL.
+func ConfirmSNSSubscription(client *http.Client, subscribeURL string) error {
+	u, err := url.Parse(subscribeURL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "https" || !snsHost.MatchString(u.Host) {
+		return fmt.Errorf("SubscribeURL %s isn't an https URL of an SNS endpoint", subscribeURL)
+	}
+	res, err := client.Get(u.String())
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("confirming subscription: %s", res.Status)
+	}
+	return nil
+}
+
+// pubSubPush is a Pub/Sub push delivery.
+type pubSubPush struct {
+	Message struct {
+		Data       string            `json:"data"`
+		Attributes map[string]string `json:"attributes,omitempty"`
+		// Pub/Sub sends both spellings of these fields.
+		MessageID        string `json:"messageId"`
+		MessageIDSnake   string `json:"message_id"`
+		PublishTime      string `json:"publishTime"`
+		PublishTimeSnake string `json:"publish_time"`
+	} `json:"message"`
+	Subscription string `json:"subscription"`
+}
+
+// PubSubPushEnvelope wraps bodies in GCP Pub/Sub push deliveries. The
+// subscription attribute sets the subscription name, other attributes become
+// message attributes.
+type PubSubPushEnvelope struct{}
+
+// Wrap returns a push delivery with body as the base64 encoded message data.
+func (PubSubPushEnvelope) Wrap(body string, attrs map[string]string) (string, http.Header, error) {
+	p := new(pubSubPush)
+	id := envelopeID()
+	now := envelopeTime()
+	p.Message.Data = base64.StdEncoding.EncodeToString([]byte(body))
+	p.Message.MessageID, p.Message.MessageIDSnake = id, id
+	p.Message.PublishTime, p.Message.PublishTimeSnake = now, now
+	if a := copyAttrs(attrs, "subscription"); len(a) > 0 {
+		p.Message.Attributes = a
+	}
+	p.Subscription = attrs["subscription"]
+	if p.Subscription == "" {
+		p.Subscription = "projects/" + AppName + "/subscriptions/" + AppName
+	}
+	b, err := json.Marshal(p)
+	if err != nil {
+		return "", nil, err
+	}
+	return string(b), http.Header{"Content-Type": {"application/json"}}, nil
+}
+
+// Unwrap returns the decoded data of a push delivery.
+func (PubSubPushEnvelope) Unwrap(body string, headers http.Header) (string, map[string]string, bool, error) {
+	p := new(pubSubPush)
+	if json.Unmarshal([]byte(body), p) != nil || p.Subscription == "" {
+		return "", nil, false, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(p.Message.Data)
+	if err != nil {
+		return "", nil, false, fmt.Errorf("message data: %v", err)
+	}
+	attrs := copyAttrs(p.Message.Attributes)
+	attrs["subscription"] = p.Subscription
+	return string(data), attrs, true, nil
+}
+
+// eventBridgeEvent is an EventBridge event as delivered to API destinations.
+type eventBridgeEvent struct {
+	Version    string          `json:"version"`
+	ID         string          `json:"id"`
+	DetailType string          `json:"detail-type"`
+	Source     string          `json:"source"`
+	Account    string          `json:"account"`
+	Time       string          `json:"time"`
+	Region     string          `json:"region"`
+	Resources  []string        `json:"resources"`
+	Detail     json.RawMessage `json:"detail"`
+}
+
+// EventBridgeEnvelope wraps JSON bodies as the detail of AWS EventBridge
+// events. The attributes source, detail-type, account, region and resources
+// (comma separated) set the corresponding event fields.
+type EventBridgeEnvelope struct{}
+
+// Wrap returns an event with body as the detail.
+func (EventBridgeEnvelope) Wrap(body string, attrs map[string]string) (string, http.Header, error) {
+	if !json.Valid([]byte(body)) {
+		return "", nil, errors.New("body must be JSON")
+	}
+	e := &eventBridgeEvent{
+		Version:    "0",
+		ID:         envelopeID(),
+		DetailType: attrs["detail-type"],
+		Source:     attrs["source"],
+		Account:    attrs["account"],
+		Time:       time.Now().UTC().Format(time.RFC3339),
+		Region:     attrs["region"],
+		Resources:  []string{},
+		Detail:     json.RawMessage(body),
+	}
+	if e.Source == "" {
+		e.Source = AppName
+	}
+	if e.Account == "" {
+		e.Account = "123456789012"
+	}
+	if e.Region == "" {
+		e.Region = "us-east-1"
+	}
+	if r := attrs["resources"]; r != "" {
+		e.Resources = strings.Split(r, ",")
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return "", nil, err
+	}
+	return string(b), http.Header{"Content-Type": {"application/json"}}, nil
+}
+
+// Unwrap returns the detail of an event.
+func (EventBridgeEnvelope) Unwrap(body string, headers http.Header) (string, map[string]string, bool, error) {
+	e := new(eventBridgeEvent)
+	if json.Unmarshal([]byte(body), e) != nil || e.DetailType == "" || len(e.Detail) == 0 {
+		return "", nil, false, nil
+	}
+	attrs := map[string]string{
+		"source":      e.Source,
+		"detail-type": e.DetailType,
+		"account":     e.Account,
+		"region":      e.Region,
+	}
+	if len(e.Resources) > 0 {
+		attrs["resources"] = strings.Join(e.Resources, ",")
+	}
+	return string(e.Detail), attrs, true, nil
+}
@@ -0,0 +1,175 @@
+package hook
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tidwall/gjson"
+	"gopkg.in/yaml.v2"
+)
+
+func TestEnvelopeYAML(t *testing.T) {
+	testcases := []struct {
+		in   string
+		want *Envelope
+	}{
+		{
+			in:   "envelope: pubsub-push\n",
+			want: &Envelope{Type: "pubsub-push"},
+		},
+		{
+			in:   "envelope:\n  type: sns\n  attributes:\n    topicArn: arn\n",
+			want: &Envelope{Type: "sns", Attributes: map[string]string{"topicArn": "arn"}},
+		},
+	}
+	for _, tc := range testcases {
+		hooks, err := New(strings.NewReader("method: POST\n" + tc.in))
+		if err != nil {
+			t.Fatalf("New(%s): %v", tc.in, err)
+		}
+		h := hooks[0]
+		if diff := cmp.Diff(tc.want, h.Envelope); diff != "" {
+			t.Errorf("New(%s): %s", tc.in, diff)
+		}
+
+		b, err := yaml.Marshal(struct {
+			Envelope *Envelope `yaml:"envelope"`
+		}{h.Envelope})
+		if err != nil {
+			t.Fatalf("Marshal: %v", err)
+		}
+		if string(b) != tc.in {
+			t.Errorf("Marshal: got %s, want %s", b, tc.in)
+		}
+	}
+}
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	body := `{"order":42}`
+	testcases := []struct {
+		envelope string
+		attrs    map[string]string
+		check    func(t *testing.T, wrapped string, headers http.Header)
+	}{
+		{
+			envelope: "sns",
+			attrs:    map[string]string{"topicArn": "arn:aws:sns:us-east-1:1:orders", "subject": "created"},
+			check: func(t *testing.T, wrapped string, headers http.Header) {
+				if got := gjson.Get(wrapped, "Message").String(); got != body {
+					t.Errorf("got Message %s", got)
+				}
+				if got := headers.Get("X-Amz-Sns-Message-Type"); got != SNSNotification {
+					t.Errorf("got message type %s", got)
+				}
+			},
+		},
+		{
+			envelope: "pubsub-push",
+			attrs:    map[string]string{"subscription": "projects/p/subscriptions/s", "k": "v"},
+			check: func(t *testing.T, wrapped string, headers http.Header) {
+				if got := gjson.Get(wrapped, "message.data").String(); got != "eyJvcmRlciI6NDJ9" {
+					t.Errorf("got data %s", got)
+				}
+				if got := gjson.Get(wrapped, "message.attributes.k").String(); got != "v" {
+					t.Errorf("got attribute %s", got)
+				}
+			},
+		},
+		{
+			envelope: "eventbridge",
+			attrs:    map[string]string{"source": "shop", "detail-type": "Order Created", "account": "1", "region": "eu-west-1"},
+			check: func(t *testing.T, wrapped string, headers http.Header) {
+				if got := gjson.Get(wrapped, "detail.order").Int(); got != 42 {
+					t.Errorf("got detail %s", wrapped)
+				}
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.envelope, func(t *testing.T) {
+			h := &Hook{
+				Method:   http.MethodPost,
+				Headers:  http.Header{"Content-Type": {"application/json"}},
+				Body:     body,
+				Envelope: &Envelope{Type: tc.envelope, Attributes: tc.attrs},
+			}
+			r, err := h.toRequest("http://localhost")
+			if err != nil {
+				t.Fatalf("toRequest: %v", err)
+			}
+			b, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tc.check(t, string(b), r.Header)
+
+			got := &Hook{Method: http.MethodPost, Headers: r.Header, Body: string(b)}
+			if err := UnwrapOption().Apply(got); err != nil {
+				t.Fatalf("unwrap: %v", err)
+			}
+			if diff := cmp.Diff(h, got); diff != "" {
+				t.Errorf("unwrap: %s", diff)
+			}
+		})
+	}
+}
+
+func TestEnvelopeErrors(t *testing.T) {
+	h := &Hook{Method: http.MethodPost, Body: "not json", Envelope: &Envelope{Type: "eventbridge"}}
+	if _, err := h.toRequest("http://localhost"); err == nil || !strings.Contains(err.Error(), "eventbridge") {
+		t.Errorf("got error %v, want eventbridge error", err)
+	}
+	h.Envelope.Type = "kinesis"
+	if _, err := h.toRequest("http://localhost"); err == nil {
+		t.Error("expected error for unknown envelope")
+	}
+
+	// Hooks without an envelope are left alone.
+	plain := &Hook{Method: http.MethodPost, Body: `{"a":1}`}
+	if err := UnwrapOption().Apply(plain); err != nil || plain.Envelope != nil {
+		t.Errorf("got envelope %v, err %v", plain.Envelope, err)
+	}
+}
+
+// transportFunc is an http.RoundTripper calling a function.
+type transportFunc func(*http.Request) (*http.Response, error)
+
+func (f transportFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestConfirmSNSSubscription(t *testing.T) {
+	var visited []string
+	client := &http.Client{Transport: transportFunc(func(r *http.Request) (*http.Response, error) {
+		visited = append(visited, r.URL.String())
+		return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+	})}
+
+	for _, u := range []string{
+		"https://sns.us-east-1.amazonaws.com/?Action=ConfirmSubscription&Token=t",
+		"https://sns.cn-north-1.amazonaws.com.cn/?Action=ConfirmSubscription&Token=t",
+	} {
+		if err := ConfirmSNSSubscription(client, u); err != nil {
+			t.Errorf("ConfirmSNSSubscription(%s): %v", u, err)
+		}
+	}
+	for _, u := range []string{
+		"http://sns.us-east-1.amazonaws.com/?Action=ConfirmSubscription",
+		"https://example.com/?Action=ConfirmSubscription",
+		"https://169.254.169.254/latest/meta-data/",
+		"https://sns.us-east-1.amazonaws.com.example.com/",
+		"https://sns.us-east-1.amazonaws.com@example.com/",
+		"https://sns.us-east-1.amazonaws.com:8080/",
+		"https://localhost:8080/admin",
+	} {
+		if err := ConfirmSNSSubscription(client, u); err == nil {
+			t.Errorf("ConfirmSNSSubscription(%s): expected an error", u)
+		}
+	}
+	if len(visited) != 2 {
+		t.Errorf("visited %v, want only the SNS URLs", visited)
+	}
+}
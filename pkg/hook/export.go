@@ -0,0 +1,171 @@
+package hook
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+var (
+	// Exporters are the supported export formats.
+	Exporters = map[string]Exporter{
+		"curl":    CurlExporter{},
+		"httpie":  HTTPieExporter{},
+		"http":    HTTPExporter{},
+		"har":     HARExporter{},
+		"postman": PostmanExporter{},
+	}
+)
+
+// Exporter writes hooks as they would be fired, so that they can be sent by
+// other tools.
+type Exporter interface {
+	Export(w io.Writer, target string, hooks []*Hook) error
+}
+
+// GetExporter returns the exporter for the given format.
+func GetExporter(format string) (Exporter, error) {
+	e, ok := Exporters[format]
+	if !ok {
+		formats := make([]string, 0, len(Exporters))
+		for f := range Exporters {
+			formats = append(formats, f)
+		}
+		sort.Strings(formats)
+		return nil, fmt.Errorf("unknown export format %q, expected one of: %s", format, strings.Join(formats, ", "))
+	}
+	return e, nil
+}
+
+// exportRequest builds the request the hook would fire, buffering the body
+// so that it can be read multiple times. ContentLength is set so that
+// serialized requests are not chunked.
+func exportRequest(h *Hook, target string) (*http.Request, []byte, error) {
+	r, err := h.toRequest(target)
+	if err != nil {
+		return nil, nil, err
+	}
+	var body []byte
+	if r.Body != nil {
+		body, err = ioutil.ReadAll(r.Body)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(body) > 0 {
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		r.ContentLength = int64(len(body))
+	}
+	return r, body, nil
+}
+
+// skipExportHeader are headers that are computed by the client sending the
+// request, and would be wrong if copied (e.g. after templating or
+// transforms changed the body).
+var skipExportHeader = map[string]bool{
+	"Content-Length":    true,
+	"Host":              true,
+	"Transfer-Encoding": true,
+}
+
+// sortedHeaders returns header key/value pairs in a deterministic order.
+func sortedHeaders(h http.Header) [][2]string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var out [][2]string
+	for _, k := range keys {
+		if skipExportHeader[http.CanonicalHeaderKey(k)] {
+			continue
+		}
+		for _, v := range h[k] {
+			out = append(out, [2]string{k, v})
+		}
+	}
+	return out
+}
+
+// shellQuote quotes s for use as a single POSIX shell word.
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// writeCommand writes a shell command split over multiple lines.
+func writeCommand(w io.Writer, lines []string) {
+	fmt.Fprintln(w, strings.Join(lines, " \\\n  "))
+}
+
+// CurlExporter exports hooks as curl commands.
+type CurlExporter struct{}
+
+// Export writes one curl command per hook.
+func (CurlExporter) Export(w io.Writer, target string, hooks []*Hook) error {
+	for _, h := range hooks {
+		r, body, err := exportRequest(h, target)
+		if err != nil {
+			return err
+		}
+		cmd := "curl"
+		if r.Method != http.MethodGet || len(body) > 0 {
+			cmd += " -X " + r.Method
+		}
+		lines := []string{cmd + " " + shellQuote(r.URL.String())}
+		for _, kv := range sortedHeaders(r.Header) {
+			lines = append(lines, "-H "+shellQuote(kv[0]+": "+kv[1]))
+		}
+		if len(body) > 0 {
+			lines = append(lines, "--data-raw "+shellQuote(string(body)))
+		}
+		writeCommand(w, lines)
+	}
+	return nil
+}
+
+// HTTPieExporter exports hooks as HTTPie commands.
+type HTTPieExporter struct{}
+
+// Export writes one HTTPie command per hook. Bodies are passed on stdin so
+// that they are sent exactly as is.
+func (HTTPieExporter) Export(w io.Writer, target string, hooks []*Hook) error {
+	for _, h := range hooks {
+		r, body, err := exportRequest(h, target)
+		if err != nil {
+			return err
+		}
+		cmd := "http " + r.Method + " " + shellQuote(r.URL.String())
+		if len(body) > 0 {
+			cmd = "printf '%s' " + shellQuote(string(body)) + " | " + cmd
+		}
+		lines := []string{cmd}
+		for _, kv := range sortedHeaders(r.Header) {
+			lines = append(lines, shellQuote(kv[0]+":"+kv[1]))
+		}
+		writeCommand(w, lines)
+	}
+	return nil
+}
+
+// HTTPExporter exports hooks as raw HTTP/1.1 requests, as written by
+// http.Request.Write. The output can be sent directly with tools like nc.
+type HTTPExporter struct{}
+
+// Export writes each hook as a raw HTTP request.
+func (HTTPExporter) Export(w io.Writer, target string, hooks []*Hook) error {
+	for _, h := range hooks {
+		r, _, err := exportRequest(h, target)
+		if err != nil {
+			return err
+		}
+		if err := r.Write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
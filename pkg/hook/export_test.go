@@ -0,0 +1,177 @@
+package hook
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func exportHook() *Hook {
+	return &Hook{
+		Method: http.MethodPost,
+		Headers: http.Header{
+			"Content-Type":   []string{"application/json"},
+			"Content-Length": []string{"999"},
+			"X-Event":        []string{"{{ .event }}"},
+		},
+		Body:      `{"it's":"bar"}`,
+		Params:    url.Values{"taco": []string{"cat"}},
+		Transform: map[TransformStrategy][]string{TransformBase64: {"it's"}},
+		Vars:      map[string]string{"event": "push"},
+	}
+}
+
+func TestExport(t *testing.T) {
+	testcases := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: "curl",
+			want: `curl -X POST 'http://example.com/hook?taco=cat' \
+  -H 'Content-Type: application/json' \
+  -H 'X-Event: push' \
+  --data-raw '{"it'\''s":"YmFy"}'
+`,
+		},
+		{
+			format: "httpie",
+			want: `printf '%s' '{"it'\''s":"YmFy"}' | http POST 'http://example.com/hook?taco=cat' \
+  'Content-Type:application/json' \
+  'X-Event:push'
+`,
+		},
+		{
+			format: "http",
+			want: "POST /hook?taco=cat HTTP/1.1\r\n" +
+				"Host: example.com\r\n" +
+				"User-Agent: Go-http-client/1.1\r\n" +
+				"Content-Length: 15\r\n" +
+				"Content-Type: application/json\r\n" +
+				"X-Event: push\r\n" +
+				"\r\n" +
+				`{"it's":"YmFy"}`,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.format, func(t *testing.T) {
+			e, err := GetExporter(tc.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b := new(bytes.Buffer)
+			if err := e.Export(b, "http://example.com/hook", []*Hook{exportHook()}); err != nil {
+				t.Fatalf("Export: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, b.String()); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+
+	if _, err := GetExporter("telegraph"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestExportSigned(t *testing.T) {
+	h := &Hook{
+		Method: http.MethodPost,
+		Body:   `{"zen":"hi"}`,
+		Signing: &Signing{
+			Header: "X-Hub-Signature-256",
+			Prefix: "sha256=",
+			Secret: "s3cret",
+		},
+	}
+	signed := `curl -X POST 'http://example.com/hook' \
+  -H 'X-Hub-Signature-256: sha256=b18ce78d18646301e6811d72dbafbde385e5ab0f65482fdb261485ca0773c8bc' \
+  --data-raw '{"zen":"hi"}'
+`
+	b := new(bytes.Buffer)
+	if err := (CurlExporter{}).Export(b, "http://example.com/hook", []*Hook{h}); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if diff := cmp.Diff(signed, b.String()); diff != "" {
+		t.Error(diff)
+	}
+
+	RedactSignatures([]*Hook{h})
+	b.Reset()
+	if err := (CurlExporter{}).Export(b, "http://example.com/hook", []*Hook{h}); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	redacted := `curl -X POST 'http://example.com/hook' \
+  -H 'X-Hub-Signature-256: sha256=REDACTED' \
+  --data-raw '{"zen":"hi"}'
+`
+	if diff := cmp.Diff(redacted, b.String()); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestExportHTTPReadable(t *testing.T) {
+	// Raw HTTP output should be readable as a stream of requests.
+	b := new(bytes.Buffer)
+	hooks := []*Hook{exportHook(), exportHook()}
+	if err := (HTTPExporter{}).Export(b, "http://example.com/hook", hooks); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	br := bufio.NewReader(b)
+	for i := range hooks {
+		r, err := http.ReadRequest(br)
+		if err != nil {
+			t.Fatalf("ReadRequest(%d): %v", i, err)
+		}
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(body) != `{"it's":"YmFy"}` {
+			t.Errorf("request %d: got body %s", i, body)
+		}
+	}
+}
+
+func TestExportHAR(t *testing.T) {
+	b := new(bytes.Buffer)
+	if err := (HARExporter{}).Export(b, "http://example.com/hook", []*Hook{exportHook()}); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	har := new(HAR)
+	if err := json.Unmarshal(b.Bytes(), har); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if n := len(har.Log.Entries); n != 1 {
+		t.Fatalf("got %d entries, want 1", n)
+	}
+	req := har.Log.Entries[0].Request
+	want := &HARRequest{
+		Method:      http.MethodPost,
+		URL:         "http://example.com/hook?taco=cat",
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []*HARNameValue{},
+		Headers: []*HARNameValue{
+			{Name: "Content-Type", Value: "application/json"},
+			{Name: "X-Event", Value: "push"},
+		},
+		QueryString: []*HARNameValue{{Name: "taco", Value: "cat"}},
+		PostData: &HARPostData{
+			MimeType: "application/json",
+			Text:     `{"it's":"YmFy"}`,
+		},
+		HeadersSize: -1,
+		BodySize:    15,
+	}
+	if diff := cmp.Diff(want, req); diff != "" {
+		t.Error(diff)
+	}
+}
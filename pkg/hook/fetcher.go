@@ -0,0 +1,184 @@
+package hook
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+var (
+	// Fetchers are the supported ways of fetching remote catalogs, selected
+	// with the catalog.fetcher setting.
+	Fetchers = map[string]Fetcher{
+		"native": NativeFetcher{},
+		"exec":   ExecFetcher{},
+	}
+
+	// DefaultFetcher is used if catalog.fetcher isn't set. The native
+	// fetcher is opt-in, as the go-git version it's built on has known
+	// vulnerabilities.
+	DefaultFetcher = "exec"
+
+	// Offline disables cloning and fetching catalogs, so only cached
+	// catalogs and the embedded snapshot of the default catalog are used.
+	Offline bool
+)
+
+// Fetcher clones and updates the caches of remote catalogs, and reads them at
+// a commit. Failures to reach the remote are returned as a *FetchError.
+type Fetcher interface {
+	// Clone clones the catalog to its cache and checks out its revision.
+	Clone(rc *RemoteConfig) error
+	// Fetch fetches a revision of the catalog, or its default branch if rev
+	// is empty, and returns its commit. What the cache has checked out is
+	// unchanged.
+	Fetch(rc *RemoteConfig, rev string) (string, error)
+	// Checkout checks out a commit in the cache.
+	Checkout(rc *RemoteConfig, commit string) error
+	// Resolve returns the commit of a revision that is in the cache.
+	Resolve(rc *RemoteConfig, rev string) (string, error)
+	// At returns the catalog as of a commit in the cache.
+	At(rc *RemoteConfig, commit string) (Catalog, error)
+}
+
+// GetFetcher returns the fetcher of the given name.
+func GetFetcher(name string) (Fetcher, error) {
+	f, ok := Fetchers[name]
+	if !ok {
+		names := make([]string, 0, len(Fetchers))
+		for n := range Fetchers {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown fetcher %q, expected one of: %s", name, strings.Join(names, ", "))
+	}
+	return f, nil
+}
+
+// fetcher returns the fetcher for the catalog, which is the configured one
+// unless the catalog is an OCI artifact or an archive. If hook is offline,
+// the fetcher only reads the cache.
+func (rc *RemoteConfig) fetcher() (Fetcher, error) {
+	var f Fetcher
+	switch {
+	case strings.HasPrefix(rc.URL, OCIScheme):
+		f = OCIFetcher{}
+	case archiveFormat(rc.URL) != "":
+		f = ArchiveFetcher{}
+	default:
+		name := viper.GetString("catalog.fetcher")
+		if name == "" {
+			name = DefaultFetcher
+		}
+		var err error
+		if f, err = GetFetcher(name); err != nil {
+			return nil, err
+		}
+		if _, ok := f.(NativeFetcher); ok && gitInstalled() {
+			f = execFallbackFetcher{f}
+		}
+	}
+	if Offline {
+		return offlineFetcher{f}, nil
+	}
+	return f, nil
+}
+
+// offlineFetcher fails to clone and fetch catalogs, see Offline.
+type offlineFetcher struct {
+	Fetcher
+}
+
+// Clone returns an error wrapping ErrOffline.
+func (offlineFetcher) Clone(rc *RemoteConfig) error {
+	return &FetchError{Catalog: rc.Name, URL: rc.URL, Op: "clone", Err: ErrOffline}
+}
+
+// Fetch returns an error wrapping ErrOffline.
+func (offlineFetcher) Fetch(rc *RemoteConfig, rev string) (string, error) {
+	return "", &FetchError{Catalog: rc.Name, URL: rc.URL, Op: "fetch", Err: ErrOffline}
+}
+
+// gitInstalled reports whether the git binary is on the PATH.
+var gitInstalled = func() bool {
+	_, err := exec.LookPath("git")
+	return err == nil
+}
+
+// execFallbackFetcher retries clones and fetches that fail with ErrAuth with
+// ExecFetcher, as git's credential helpers and ssh configuration may be able
+// to authenticate where the native fetcher can't.
+type execFallbackFetcher struct {
+	Fetcher
+}
+
+// Clone clones the catalog, falling back to git on ErrAuth.
+func (f execFallbackFetcher) Clone(rc *RemoteConfig) error {
+	err := f.Fetcher.Clone(rc)
+	if !errors.Is(err, ErrAuth) {
+		return err
+	}
+	log.Printf("%v, retrying with git", err)
+	// Anything left in the cache is from the failed clone.
+	if err := os.RemoveAll(rc.Path()); err != nil {
+		return err
+	}
+	return ExecFetcher{}.Clone(rc)
+}
+
+// Fetch fetches a revision of the catalog, falling back to git on ErrAuth.
+func (f execFallbackFetcher) Fetch(rc *RemoteConfig, rev string) (string, error) {
+	commit, err := f.Fetcher.Fetch(rc, rev)
+	if !errors.Is(err, ErrAuth) {
+		return commit, err
+	}
+	log.Printf("%v, retrying with git", err)
+	return ExecFetcher{}.Fetch(rc, rev)
+}
+
+// Kinds of fetch errors, see FetchError.
+var (
+	ErrAuth        = errors.New("authentication failed")
+	ErrRefNotFound = errors.New("repository or revision not found")
+	ErrNetwork     = errors.New("network error")
+)
+
+// ErrOffline is wrapped by the errors of catalogs that would have to be
+// fetched while Offline is set.
+var ErrOffline = errors.New("hook is offline")
+
+// FetchError describes a failure to clone or fetch a catalog. errors.Is
+// reports whether it is one of ErrAuth, ErrRefNotFound or ErrNetwork.
+type FetchError struct {
+	Catalog string
+	URL     string
+	// Op is the operation that failed, such as clone or fetch.
+	Op string
+	// Kind is the kind of failure, or nil if it isn't known.
+	Kind error
+	Err  error
+}
+
+func (e *FetchError) Error() string {
+	s := fmt.Sprintf("catalog %s: %s %s", e.Catalog, e.Op, e.URL)
+	if e.Kind != nil {
+		s += ": " + e.Kind.Error()
+	}
+	return s + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *FetchError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the kind of the error.
+func (e *FetchError) Is(target error) bool {
+	return e.Kind != nil && target == e.Kind
+}
@@ -0,0 +1,406 @@
+package hook
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// archiveMetaFile records where the archive extracted to the cache of a
+// catalog came from, for conditional requests.
+const archiveMetaFile = ".hook-archive.json"
+
+// archiveClient downloads archives, including from file:// URLs.
+var archiveClient = func() *http.Client {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.RegisterProtocol("file", http.NewFileTransport(http.Dir("/")))
+	return &http.Client{Transport: t}
+}()
+
+// archiveExtensions map the extensions of archive URLs to their formats.
+var archiveExtensions = []struct {
+	ext, format string
+}{
+	{".tar.gz", "tar.gz"},
+	{".tgz", "tar.gz"},
+	{".zip", "zip"},
+}
+
+// archiveFormat returns the format of the archive at rawurl, or "" if it
+// isn't an archive.
+func archiveFormat(rawurl string) string {
+	p := rawurl
+	if u, err := url.Parse(rawurl); err == nil {
+		p = u.Path
+	}
+	p = strings.ToLower(p)
+	for _, e := range archiveExtensions {
+		if strings.HasSuffix(p, e.ext) {
+			return e.format
+		}
+	}
+	return ""
+}
+
+// ArchiveFetcher fetches catalogs published as .tar.gz or .zip archives from
+// http(s):// and file:// URLs. It is used for archive URLs regardless of
+// catalog.fetcher.
+//
+// Archives have no history, so the revision of a catalog is the digest of the
+// archive in its cache, sha256:<hex>. If the catalog configures a Checksum,
+// archives with a different digest are rejected.
+type ArchiveFetcher struct{}
+
+type archiveMeta struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	Digest       string `json:"digest"`
+}
+
+// readArchiveMeta returns the metadata of the archive extracted to dir, or
+// nil if there is none.
+func readArchiveMeta(dir string) (*archiveMeta, error) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, archiveMetaFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	m := new(archiveMeta)
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("%s: %v", filepath.Join(dir, archiveMetaFile), err)
+	}
+	return m, nil
+}
+
+// archiveStage is where an archive is extracted to before it is checked out.
+func archiveStage(rc *RemoteConfig, digest string) string {
+	sum := strings.TrimPrefix(digest, "sha256:")
+	if len(sum) > 12 {
+		sum = sum[:12]
+	}
+	return filepath.Join(filepath.Dir(rc.Path()), "."+rc.Name+"-"+sum)
+}
+
+// Clone downloads and extracts the archive.
+func (f ArchiveFetcher) Clone(rc *RemoteConfig) error {
+	digest, err := f.Fetch(rc, "")
+	if err != nil {
+		return err
+	}
+	return f.Checkout(rc, digest)
+}
+
+// Fetch downloads the archive, unless it hasn't changed since it was last
+// downloaded, and extracts it next to the cache. Archives have no revisions,
+// so rev has to be empty.
+func (ArchiveFetcher) Fetch(rc *RemoteConfig, rev string) (string, error) {
+	if rev != "" {
+		return "", &FetchError{Catalog: rc.Name, URL: rc.URL, Op: "fetch", Kind: ErrRefNotFound, Err: fmt.Errorf("archives have no revision %s", rev)}
+	}
+	if rc.Checksum != "" && !strings.HasPrefix(rc.Checksum, "sha256:") {
+		return "", fmt.Errorf("catalog %s: unsupported checksum %q, expected sha256:<hex>", rc.Name, rc.Checksum)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, rc.URL, nil)
+	if err != nil {
+		return "", fmt.Errorf("catalog %s: %v", rc.Name, err)
+	}
+	meta, err := readArchiveMeta(rc.Path())
+	if err != nil {
+		return "", fmt.Errorf("catalog %s: %v", rc.Name, err)
+	}
+	// The archive is downloaded again if the catalog now has another URL or
+	// checksum.
+	if meta != nil && (meta.URL != rc.URL || rc.Checksum != "" && !strings.EqualFold(rc.Checksum, meta.Digest)) {
+		meta = nil
+	}
+	if meta != nil {
+		if meta.ETag != "" {
+			req.Header.Set("If-None-Match", meta.ETag)
+		}
+		if meta.LastModified != "" {
+			req.Header.Set("If-Modified-Since", meta.LastModified)
+		}
+	}
+
+	resp, err := archiveClient.Do(req)
+	if err != nil {
+		return "", archiveFetchError(rc, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		if meta != nil {
+			return meta.Digest, nil
+		}
+		return "", &FetchError{Catalog: rc.Name, URL: rc.URL, Op: "download", Err: errors.New(resp.Status)}
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return "", &FetchError{Catalog: rc.Name, URL: rc.URL, Op: "download", Kind: ErrAuth, Err: errors.New(resp.Status)}
+	case http.StatusNotFound, http.StatusGone:
+		return "", &FetchError{Catalog: rc.Name, URL: rc.URL, Op: "download", Kind: ErrRefNotFound, Err: errors.New(resp.Status)}
+	default:
+		return "", &FetchError{Catalog: rc.Name, URL: rc.URL, Op: "download", Err: errors.New(resp.Status)}
+	}
+
+	return stageArchive(rc, resp)
+}
+
+// stageArchive verifies the downloaded archive and extracts it to its
+// stage, returning its digest.
+func stageArchive(rc *RemoteConfig, resp *http.Response) (string, error) {
+	tmp, digest, err := download(rc, resp.Body)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if rc.Checksum != "" && !strings.EqualFold(rc.Checksum, digest) {
+		return "", fmt.Errorf("catalog %s: checksum mismatch, %s has %s, want %s", rc.Name, rc.URL, digest, rc.Checksum)
+	}
+	return digest, extractStage(rc, archiveFormat(rc.URL), true, tmp, &archiveMeta{
+		URL:          rc.URL,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Digest:       digest,
+	})
+}
+
+// download writes r to a temporary file next to the cache and returns it
+// with the digest of its contents. The caller removes the file.
+func download(rc *RemoteConfig, r io.Reader) (*os.File, string, error) {
+	cache := filepath.Dir(rc.Path())
+	if err := os.MkdirAll(cache, 0755); err != nil {
+		return nil, "", err
+	}
+	tmp, err := ioutil.TempFile(cache, "."+rc.Name+"-*.download")
+	if err != nil {
+		return nil, "", err
+	}
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, h), r); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, "", archiveFetchError(rc, err)
+	}
+	return tmp, "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// extractStage extracts an archive to the stage of meta.Digest, from where it is
+// checked out.
+func extractStage(rc *RemoteConfig, format string, strip bool, f *os.File, meta *archiveMeta) error {
+	dir := archiveStage(rc, meta.Digest)
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if err := extractArchive(format, strip, f, dir); err != nil {
+		os.RemoveAll(dir)
+		return fmt.Errorf("catalog %s: extracting %s: %v", rc.Name, rc.URL, err)
+	}
+	b, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, archiveMetaFile), b, 0644)
+}
+
+// Checkout replaces the cache with the fetched archive.
+func (ArchiveFetcher) Checkout(rc *RemoteConfig, digest string) error {
+	stage := archiveStage(rc, digest)
+	if meta, err := readArchiveMeta(rc.Path()); err == nil && meta != nil && meta.Digest == digest {
+		return os.RemoveAll(stage)
+	}
+	if _, err := os.Stat(stage); err != nil {
+		return fmt.Errorf("catalog %s: archive %s has not been fetched", rc.Name, digest)
+	}
+	if err := os.RemoveAll(rc.Path()); err != nil {
+		return err
+	}
+	return os.Rename(stage, rc.Path())
+}
+
+// Resolve returns the digest of the archive in the cache for HEAD. Other
+// revisions have to be the digest of the archive in the cache or of one that
+// has been fetched but not checked out.
+func (ArchiveFetcher) Resolve(rc *RemoteConfig, rev string) (string, error) {
+	meta, err := readArchiveMeta(rc.Path())
+	if err != nil {
+		return "", fmt.Errorf("catalog %s: %v", rc.Name, err)
+	}
+	if meta != nil && (rev == "HEAD" || strings.EqualFold(rev, meta.Digest)) {
+		return meta.Digest, nil
+	}
+	if strings.HasPrefix(rev, "sha256:") {
+		if m, err := readArchiveMeta(archiveStage(rc, rev)); err == nil && m != nil && strings.EqualFold(rev, m.Digest) {
+			return m.Digest, nil
+		}
+	}
+	if meta == nil && rev == "HEAD" {
+		return "", fmt.Errorf("catalog %s: no archive in the cache", rc.Name)
+	}
+	return "", fmt.Errorf("catalog %s: unknown revision %s", rc.Name, rev)
+}
+
+// At returns the files of the archive with the given digest.
+func (f ArchiveFetcher) At(rc *RemoteConfig, digest string) (Catalog, error) {
+	digest, err := f.Resolve(rc, digest)
+	if err != nil {
+		return nil, err
+	}
+	if meta, err := readArchiveMeta(rc.Path()); err == nil && meta != nil && meta.Digest == digest {
+		return DirCatalog(rc.Path()), nil
+	}
+	return DirCatalog(archiveStage(rc, digest)), nil
+}
+
+// archiveFetchError classifies an error downloading an archive.
+func archiveFetchError(rc *RemoteConfig, err error) error {
+	fe := &FetchError{Catalog: rc.Name, URL: rc.URL, Op: "download", Err: err}
+	var ne net.Error
+	if errors.As(err, &ne) {
+		fe.Kind = ErrNetwork
+	}
+	return fe
+}
+
+// extractArchive extracts an archive to dir. If strip is set and all files of
+// the archive are in a single top level directory, as in release artifacts,
+// its contents are extracted instead.
+func extractArchive(format string, strip bool, f *os.File, dir string) error {
+	tmp := dir + ".extract"
+	if err := os.RemoveAll(tmp); err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	var err error
+	switch format {
+	case "tar.gz":
+		err = extractTarGz(f, tmp)
+	case "zip":
+		err = extractZip(f, tmp)
+	default:
+		err = fmt.Errorf("unknown archive format %q", format)
+	}
+	if err != nil {
+		return err
+	}
+
+	root := tmp
+	if entries, err := ioutil.ReadDir(tmp); err != nil {
+		return err
+	} else if strip && len(entries) == 1 && entries[0].IsDir() {
+		root = filepath.Join(tmp, entries[0].Name())
+	}
+	return os.Rename(root, dir)
+}
+
+func extractTarGz(f *os.File, dir string) error {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = extractDir(dir, hdr.Name)
+		case tar.TypeReg:
+			err = extractFile(dir, hdr.Name, tr)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return os.MkdirAll(dir, 0755)
+}
+
+func extractZip(f *os.File, dir string) error {
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	zr, err := zip.NewReader(f, fi.Size())
+	if err != nil {
+		return err
+	}
+	for _, zf := range zr.File {
+		if zf.FileInfo().IsDir() {
+			if err := extractDir(dir, zf.Name); err != nil {
+				return err
+			}
+			continue
+		}
+		if !zf.Mode().IsRegular() {
+			continue
+		}
+		r, err := zf.Open()
+		if err != nil {
+			return err
+		}
+		err = extractFile(dir, zf.Name, r)
+		r.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return os.MkdirAll(dir, 0755)
+}
+
+// extractDir creates the directory name of an archive below dir. Names can't
+// escape dir.
+func extractDir(dir, name string) error {
+	return os.MkdirAll(filepath.Join(dir, filepath.FromSlash(cleanTreePath(name))), 0755)
+}
+
+// extractFile writes the file name of an archive below dir. Names can't
+// escape dir.
+func extractFile(dir, name string, r io.Reader) error {
+	name = cleanTreePath(name)
+	if name == "" {
+		return nil
+	}
+	p := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(p)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,225 @@
+package hook
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// testTarGz returns a .tar.gz archive of the given files.
+func testTarGz(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(files[name])), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(files[name])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// testZip returns a .zip archive of the given files.
+func testZip(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, data := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func testDigest(b []byte) string {
+	sum := sha256.Sum256(b)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func TestArchiveFormat(t *testing.T) {
+	for u, want := range map[string]string{
+		"https://example.com/catalog.tar.gz":         "tar.gz",
+		"https://example.com/catalog.TGZ?token=abc":  "tar.gz",
+		"file:///tmp/catalog.zip":                    "zip",
+		"https://github.com/eddiezane/hook-catalog":  "",
+		"https://example.com/catalog.zip/index.html": "",
+	} {
+		if got := archiveFormat(u); got != want {
+			t.Errorf("archiveFormat(%s) = %q, want %q", u, got, want)
+		}
+	}
+}
+
+func TestArchiveFetcher(t *testing.T) {
+	d := testdirInit(t)
+	defer os.RemoveAll(d)
+
+	archive := testTarGz(t, map[string]string{
+		"catalog-1.0/github/push.yaml": "method: POST\n",
+		"catalog-1.0/README.md":        "hi",
+	})
+	etag := `"v1"`
+	var requests, downloads int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/catalog.tar.gz" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		downloads++
+		w.Header().Set("ETag", etag)
+		w.Write(archive)
+	}))
+	defer srv.Close()
+
+	viper.Set("catalog.remote", []*RemoteConfig{{Name: "arc", URL: srv.URL + "/catalog.tar.gz"}})
+	hooks, err := NewFromPath("arc@github/push")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hooks[0].Method != "POST" {
+		t.Errorf("got method %s, want POST", hooks[0].Method)
+	}
+	rc, err := GetRemoteConfig("arc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v1 := testDigest(archive)
+	if commit, err := rc.Commit(); err != nil || commit != v1 {
+		t.Errorf("Commit() = (%s, %v), want %s", commit, err, v1)
+	}
+
+	// Unchanged archives aren't downloaded again.
+	if err := rc.Update(); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 2 || downloads != 1 {
+		t.Errorf("got %d requests and %d downloads, want 2 and 1", requests, downloads)
+	}
+
+	archive = testTarGz(t, map[string]string{
+		"github/push.yaml":   "method: PUT\n",
+		"stripe/charge.yaml": "method: POST\n",
+	})
+	etag = `"v2"`
+	if err := rc.Update(); err != nil {
+		t.Fatal(err)
+	}
+	if hooks, err = NewFromPath("arc@github/push"); err != nil || hooks[0].Method != "PUT" {
+		t.Errorf("NewFromPath after update = (%v, %v)", hooks, err)
+	}
+	if _, err := os.Stat(filepath.Join(rc.Path(), "README.md")); !os.IsNotExist(err) {
+		t.Errorf("stale file after update: %v", err)
+	}
+	if entries, _ := ioutil.ReadDir(filepath.Dir(rc.Path())); len(entries) != 1 {
+		t.Errorf("cache has %d entries, want only the catalog", len(entries))
+	}
+
+	// Archives are locked by their digest, and have no other revisions.
+	if _, err := rc.At(testDigest(archive)); err != nil {
+		t.Error(err)
+	}
+	if _, err := NewFromPath("arc@github/push#v1"); !errors.Is(err, ErrRefNotFound) {
+		t.Errorf("got %v, want ErrRefNotFound", err)
+	}
+
+	missing := &RemoteConfig{Name: "missing", URL: srv.URL + "/missing.zip"}
+	if err := missing.Clone(); !errors.Is(err, ErrRefNotFound) {
+		t.Errorf("got %v, want ErrRefNotFound", err)
+	}
+}
+
+func TestArchiveFetcherFile(t *testing.T) {
+	d := testdirInit(t)
+	defer os.RemoveAll(d)
+
+	archive := testZip(t, map[string]string{
+		"github/":          "",
+		"github/push.yaml": "method: POST\n",
+		"../escape.yaml":   "method: GET\n",
+	})
+	path := filepath.Join(d, "catalog.zip")
+	if err := ioutil.WriteFile(path, archive, 0644); err != nil {
+		t.Fatal(err)
+	}
+	url := "file://" + filepath.ToSlash(path)
+
+	bad := &RemoteConfig{Name: "bad", URL: url, Checksum: "sha256:0123"}
+	if err := bad.Clone(); err == nil {
+		t.Error("expected checksum mismatch")
+	}
+	if bad.isCached() {
+		t.Error("archive with the wrong checksum was extracted")
+	}
+
+	rc := &RemoteConfig{Name: "zip", URL: url, Checksum: testDigest(archive)}
+	if err := rc.Clone(); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	if err := WalkCatalog(rc, "", func(p string) error {
+		got = append(got, p)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != "escape.yaml" || got[1] != "github/push.yaml" {
+		t.Errorf("WalkCatalog = %v", got)
+	}
+
+	// file:// URLs are checked for modifications too.
+	meta, err := readArchiveMeta(rc.Path())
+	if err != nil || meta == nil || meta.LastModified == "" {
+		t.Errorf("readArchiveMeta = (%+v, %v), want a modification time", meta, err)
+	}
+	if err := rc.Update(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := rc.Update(); !errors.Is(err, ErrRefNotFound) {
+		t.Errorf("got %v, want ErrRefNotFound", err)
+	}
+}
@@ -0,0 +1,146 @@
+package hook
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+var newCommand func(name, command string, args ...string) runnable = execCommand
+
+type runnable interface {
+	// Output runs the command and returns its standard output.
+	Output() ([]byte, error)
+}
+
+func execCommand(_, command string, args ...string) runnable {
+	return exec.Command(command, args...)
+}
+
+// ExecFetcher fetches catalogs with the git binary, so that its credential
+// helpers and ssh configuration are used.
+type ExecFetcher struct{}
+
+// runGit runs git on behalf of a catalog and returns its output. Errors
+// include what git wrote to stderr.
+func runGit(name string, args ...string) ([]byte, error) {
+	out, err := newCommand(name, "git", args...).Output()
+	if ee, ok := err.(*exec.ExitError); ok && len(ee.Stderr) > 0 {
+		err = fmt.Errorf("git: %s", strings.TrimSpace(string(ee.Stderr)))
+	}
+	return out, err
+}
+
+// git runs a git command in the cache of the catalog and returns its output.
+func (rc *RemoteConfig) git(args ...string) ([]byte, error) {
+	gitdir := filepath.Join(rc.Path(), ".git")
+	return runGit(rc.Name, append([]string{"--git-dir", gitdir}, args...)...)
+}
+
+// Clone runs git clone, checking out the configured revision.
+func (ExecFetcher) Clone(rc *RemoteConfig) error {
+	args := []string{"clone", "-q"}
+	if rc.Revision != "" {
+		args = append(args, "-b", rc.Revision)
+	}
+	args = append(args, rc.URL, rc.Path())
+	if _, err := runGit(rc.Name, args...); err != nil {
+		return execFetchError(rc, "clone", err)
+	}
+	return nil
+}
+
+// Fetch runs git fetch and returns the fetched commit.
+func (ExecFetcher) Fetch(rc *RemoteConfig, rev string) (string, error) {
+	args := []string{"fetch", "-q", "origin"}
+	if rev != "" {
+		args = append(args, rev)
+	}
+	if _, err := rc.git(args...); err != nil {
+		return "", execFetchError(rc, "fetch", err)
+	}
+	out, err := rc.git("rev-parse", "FETCH_HEAD")
+	if err != nil {
+		return "", fmt.Errorf("catalog %s: %v", rc.Name, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Checkout runs git checkout in the cache.
+func (ExecFetcher) Checkout(rc *RemoteConfig, commit string) error {
+	_, err := rc.git("--work-tree", rc.Path(),
+		"-c", "advice.detachedHead=false",
+		"checkout", "-q", commit)
+	if err != nil {
+		return fmt.Errorf("catalog %s: %v", rc.Name, err)
+	}
+	return nil
+}
+
+// Resolve runs git rev-parse.
+func (ExecFetcher) Resolve(rc *RemoteConfig, rev string) (string, error) {
+	out, err := rc.git("rev-parse", "--verify", "-q", rev+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("catalog %s: unknown revision %s", rc.Name, rev)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// At reads files with git show and git ls-tree.
+func (ExecFetcher) At(rc *RemoteConfig, commit string) (Catalog, error) {
+	return &revCatalog{rc: rc, commit: commit}, nil
+}
+
+// gitErrors map messages git prints to the kind of error, in the order they
+// are checked.
+var gitErrors = []struct {
+	kind     error
+	messages []string
+}{
+	{ErrAuth, []string{
+		"authentication failed",
+		"could not read username",
+		"could not read password",
+		"permission denied",
+		"terminal prompts disabled",
+		"returned error: 401",
+		"returned error: 403",
+	}},
+	{ErrRefNotFound, []string{
+		"repository not found",
+		"does not appear to be a git repository",
+		"not found in upstream",
+		"couldn't find remote ref",
+		"returned error: 404",
+	}},
+	{ErrNetwork, []string{
+		"could not resolve host",
+		"failed to connect",
+		"connection refused",
+		"connection reset",
+		"timed out",
+		"network is unreachable",
+		"unable to access",
+	}},
+}
+
+// execFetchError classifies an error of git by its message.
+func execFetchError(rc *RemoteConfig, op string, err error) error {
+	fe := &FetchError{Catalog: rc.Name, URL: rc.URL, Op: op, Err: err}
+	if errors.Is(err, exec.ErrNotFound) {
+		fe.Err = fmt.Errorf("%v, set catalog.fetcher to native to fetch without git", err)
+		return fe
+	}
+	msg := strings.ToLower(err.Error())
+	for _, e := range gitErrors {
+		for _, m := range e.messages {
+			if strings.Contains(msg, m) {
+				fe.Kind = e.kind
+				return fe
+			}
+		}
+	}
+	return fe
+}
@@ -0,0 +1,306 @@
+package hook
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+// NativeFetcher fetches catalogs with a git implementation written in Go, so
+// git doesn't have to be installed. It is used if catalog.fetcher is native.
+// It doesn't use git's credential helpers, so private catalogs are fetched
+// with ExecFetcher if authentication fails and git is installed.
+type NativeFetcher struct{}
+
+// Clone clones all branches and tags of the catalog and checks out its
+// revision.
+func (NativeFetcher) Clone(rc *RemoteConfig) error {
+	repo, err := git.PlainClone(rc.Path(), false, &git.CloneOptions{URL: rc.URL, Tags: git.AllTags})
+	if err != nil {
+		return nativeFetchError(rc, "clone", err)
+	}
+	if rc.Revision == "" {
+		return nil
+	}
+
+	// Branches are only cloned as remote branches.
+	commit, err := resolveCommit(repo, plumbing.NewRemoteReferenceName(git.DefaultRemoteName, rc.Revision).String())
+	if err != nil {
+		commit, err = resolveCommit(repo, rc.Revision)
+	}
+	if err == nil {
+		var wt *git.Worktree
+		if wt, err = repo.Worktree(); err == nil {
+			err = wt.Checkout(&git.CheckoutOptions{Hash: commit.Hash, Force: true})
+		}
+	}
+	if err != nil {
+		os.RemoveAll(rc.Path())
+		return &FetchError{Catalog: rc.Name, URL: rc.URL, Op: "clone", Kind: ErrRefNotFound, Err: err}
+	}
+	return nil
+}
+
+// Fetch fetches the branch or tag rev, or all branches if rev is a commit.
+func (NativeFetcher) Fetch(rc *RemoteConfig, rev string) (string, error) {
+	repo, err := git.PlainOpen(rc.Path())
+	if err != nil {
+		return "", fmt.Errorf("catalog %s: %v", rc.Name, err)
+	}
+	remote, err := repo.Remote(git.DefaultRemoteName)
+	if err != nil {
+		return "", fmt.Errorf("catalog %s: %v", rc.Name, err)
+	}
+	refs, err := remote.List(&git.ListOptions{})
+	if err != nil {
+		return "", nativeFetchError(rc, "fetch", err)
+	}
+
+	target := rev
+	spec := config.RefSpec(fmt.Sprintf("+refs/heads/*:refs/remotes/%s/*", git.DefaultRemoteName))
+	if src, dst := remoteRef(refs, rev); src != "" {
+		target = dst.String()
+		spec = config.RefSpec("+" + src + ":" + dst)
+	} else if !commitPrefix.MatchString(rev) {
+		return "", &FetchError{Catalog: rc.Name, URL: rc.URL, Op: "fetch", Kind: ErrRefNotFound, Err: fmt.Errorf("no branch or tag %s", rev)}
+	}
+
+	err = repo.Fetch(&git.FetchOptions{RefSpecs: []config.RefSpec{spec}, Tags: git.NoTags})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return "", nativeFetchError(rc, "fetch", err)
+	}
+	commit, err := resolveCommit(repo, target)
+	if err != nil {
+		return "", &FetchError{Catalog: rc.Name, URL: rc.URL, Op: "fetch", Kind: ErrRefNotFound, Err: err}
+	}
+	return commit.Hash.String(), nil
+}
+
+// Checkout checks out commit, discarding changes to the cache.
+func (NativeFetcher) Checkout(rc *RemoteConfig, commit string) error {
+	repo, err := git.PlainOpen(rc.Path())
+	if err != nil {
+		return fmt.Errorf("catalog %s: %v", rc.Name, err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("catalog %s: %v", rc.Name, err)
+	}
+	if err := wt.Checkout(&git.CheckoutOptions{Hash: plumbing.NewHash(commit), Force: true}); err != nil {
+		return fmt.Errorf("catalog %s: checkout %s: %v", rc.Name, commit, err)
+	}
+	return nil
+}
+
+// Resolve resolves branches, tags and full or abbreviated commits.
+func (NativeFetcher) Resolve(rc *RemoteConfig, rev string) (string, error) {
+	repo, err := git.PlainOpen(rc.Path())
+	if err != nil {
+		return "", fmt.Errorf("catalog %s: %v", rc.Name, err)
+	}
+	commit, err := resolveCommit(repo, rev)
+	if err != nil {
+		return "", fmt.Errorf("catalog %s: %v", rc.Name, err)
+	}
+	return commit.Hash.String(), nil
+}
+
+// At reads files from the tree of the commit.
+func (NativeFetcher) At(rc *RemoteConfig, commit string) (Catalog, error) {
+	repo, err := git.PlainOpen(rc.Path())
+	if err != nil {
+		return nil, fmt.Errorf("catalog %s: %v", rc.Name, err)
+	}
+	c, err := repo.CommitObject(plumbing.NewHash(commit))
+	if err != nil {
+		return nil, fmt.Errorf("catalog %s: commit %s: %v", rc.Name, commit, err)
+	}
+	tree, err := c.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("catalog %s: commit %s: %v", rc.Name, commit, err)
+	}
+	return &treeCatalog{tree: tree}, nil
+}
+
+// commitPrefix matches revisions that may be abbreviated commits.
+var commitPrefix = regexp.MustCompile(`^[0-9a-f]{4,40}$`)
+
+// resolveCommit returns the commit of a revision, which unlike
+// Repository.ResolveRevision may be an abbreviated commit.
+func resolveCommit(repo *git.Repository, rev string) (*object.Commit, error) {
+	if h, err := repo.ResolveRevision(plumbing.Revision(rev)); err == nil {
+		return repo.CommitObject(*h)
+	}
+	if !commitPrefix.MatchString(rev) {
+		return nil, fmt.Errorf("unknown revision %s", rev)
+	}
+
+	iter, err := repo.CommitObjects()
+	if err != nil {
+		return nil, err
+	}
+	var found *object.Commit
+	err = iter.ForEach(func(c *object.Commit) error {
+		if !strings.HasPrefix(c.Hash.String(), rev) {
+			return nil
+		}
+		if found != nil {
+			return fmt.Errorf("ambiguous commit %s", rev)
+		}
+		found = c
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, fmt.Errorf("unknown revision %s", rev)
+	}
+	return found, nil
+}
+
+// remoteRef finds the branch or tag rev in the refs of a remote, or the
+// branch of its HEAD if rev is empty. It returns the name of the ref and
+// where to fetch it to, or empty names if there is no such ref.
+func remoteRef(refs []*plumbing.Reference, rev string) (src, dst plumbing.ReferenceName) {
+	byName := make(map[plumbing.ReferenceName]*plumbing.Reference, len(refs))
+	for _, r := range refs {
+		byName[r.Name()] = r
+	}
+	if rev == "" {
+		head, ok := byName[plumbing.HEAD]
+		if !ok {
+			return "", ""
+		}
+		if head.Type() == plumbing.SymbolicReference {
+			rev = head.Target().String()
+		}
+		for _, r := range refs {
+			if rev == "" && r.Name().IsBranch() && r.Hash() == head.Hash() {
+				rev = r.Name().String()
+			}
+		}
+	}
+
+	for _, name := range []plumbing.ReferenceName{
+		plumbing.ReferenceName(rev),
+		plumbing.NewBranchReferenceName(rev),
+		plumbing.NewTagReferenceName(rev),
+	} {
+		if _, ok := byName[name]; !ok {
+			continue
+		}
+		switch {
+		case name.IsBranch():
+			return name, plumbing.NewRemoteReferenceName(git.DefaultRemoteName, name.Short())
+		case name.IsTag():
+			return name, name
+		}
+	}
+	return "", ""
+}
+
+// nativeFetchError classifies an error of go-git.
+func nativeFetchError(rc *RemoteConfig, op string, err error) error {
+	fe := &FetchError{Catalog: rc.Name, URL: rc.URL, Op: op, Err: err}
+	cause := err
+	if ue, ok := err.(*plumbing.UnexpectedError); ok {
+		cause = ue.Err
+	}
+	var ne net.Error
+	switch {
+	case cause == transport.ErrAuthenticationRequired,
+		cause == transport.ErrAuthorizationFailed,
+		cause == transport.ErrInvalidAuthMethod,
+		strings.Contains(cause.Error(), "unable to authenticate"):
+		fe.Kind = ErrAuth
+	case cause == transport.ErrRepositoryNotFound,
+		cause == transport.ErrEmptyRemoteRepository,
+		cause == plumbing.ErrReferenceNotFound:
+		fe.Kind = ErrRefNotFound
+	case errors.As(cause, &ne):
+		fe.Kind = ErrNetwork
+	}
+	return fe
+}
+
+// treeCatalog reads the files of a git tree.
+type treeCatalog struct {
+	tree *object.Tree
+}
+
+// Open reads the file at p from the tree.
+func (c *treeCatalog) Open(p string) (io.ReadCloser, error) {
+	fi, err := c.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+	if fi.IsDir() {
+		return nil, &os.PathError{Op: "open", Path: p, Err: fmt.Errorf("is a directory")}
+	}
+	f, err := c.tree.File(cleanTreePath(p))
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: p, Err: err}
+	}
+	return f.Reader()
+}
+
+// Stat returns information about the file or directory at p in the tree.
+func (c *treeCatalog) Stat(p string) (os.FileInfo, error) {
+	p = cleanTreePath(p)
+	if p == "" {
+		return memFileInfo{name: "/", dir: true}, nil
+	}
+	e, err := c.tree.FindEntry(p)
+	if err != nil {
+		return nil, &os.PathError{Op: "stat", Path: p, Err: os.ErrNotExist}
+	}
+	return c.info(e)
+}
+
+// ReadDir returns the entries of the directory at p in the tree.
+func (c *treeCatalog) ReadDir(p string) ([]os.FileInfo, error) {
+	p = cleanTreePath(p)
+	dir := c.tree
+	if p != "" {
+		var err error
+		if dir, err = c.tree.Tree(p); err != nil {
+			return nil, &os.PathError{Op: "readdir", Path: p, Err: os.ErrNotExist}
+		}
+	}
+	out := make([]os.FileInfo, 0, len(dir.Entries))
+	for i := range dir.Entries {
+		fi, err := c.info(&dir.Entries[i])
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, fi)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Name() < out[j].Name()
+	})
+	return out, nil
+}
+
+func (c *treeCatalog) info(e *object.TreeEntry) (os.FileInfo, error) {
+	if e.Mode == filemode.Dir {
+		return memFileInfo{name: e.Name, dir: true}, nil
+	}
+	f, err := c.tree.TreeEntryFile(e)
+	if err != nil {
+		return nil, err
+	}
+	return memFileInfo{name: e.Name, size: f.Size}, nil
+}
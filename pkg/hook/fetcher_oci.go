@@ -0,0 +1,99 @@
+package hook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// OCIFetcher pulls catalogs pushed as OCI artifacts by PushCatalog from
+// oci://<registry>/<repository>[:<tag>|@<digest>] URLs. It is used for oci://
+// URLs regardless of catalog.fetcher.
+//
+// The revision of a catalog is the digest of its artifact's manifest, and
+// other tags of the repository can be fetched as revisions. If the catalog
+// configures a Checksum, its tag has to have that digest.
+type OCIFetcher struct {
+	// The pulled artifacts are staged and checked out like archives.
+	ArchiveFetcher
+}
+
+// Clone pulls the artifact.
+func (f OCIFetcher) Clone(rc *RemoteConfig) error {
+	digest, err := f.Fetch(rc, "")
+	if err != nil {
+		return err
+	}
+	return f.Checkout(rc, digest)
+}
+
+// Fetch pulls the artifact of a tag or digest of the repository, or of the
+// catalog's URL if rev is empty, unless it has been pulled before.
+func (f OCIFetcher) Fetch(rc *RemoteConfig, rev string) (string, error) {
+	ref, err := parseOCIReference(rc.URL)
+	if err != nil {
+		return "", fmt.Errorf("catalog %s: %v", rc.Name, err)
+	}
+	if strings.HasPrefix(rev, "sha256:") {
+		ref.Tag, ref.Digest = "", rev
+	} else if rev != "" {
+		ref.Tag, ref.Digest = rev, ""
+	}
+
+	c := newRegistryClient(ref)
+	digest, err := c.manifestDigest(ref.reference())
+	if err != nil {
+		return "", ociFetchError(rc, "pull", err)
+	}
+	if rev == "" && rc.Checksum != "" && !strings.EqualFold(rc.Checksum, digest) {
+		return "", fmt.Errorf("catalog %s: checksum mismatch, %s has %s, want %s", rc.Name, rc.URL, digest, rc.Checksum)
+	}
+	if _, err := f.Resolve(rc, digest); err == nil {
+		return digest, nil
+	}
+
+	m, digest, err := c.manifest(digest)
+	if err != nil {
+		return "", ociFetchError(rc, "pull", err)
+	}
+	layer, err := m.catalogLayer()
+	if err != nil {
+		return "", fmt.Errorf("catalog %s: %v", rc.Name, err)
+	}
+	blob, err := c.blob(layer.Digest)
+	if err != nil {
+		return "", ociFetchError(rc, "pull", err)
+	}
+	tmp, layerDigest, err := download(rc, blob)
+	blob.Close()
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	if layerDigest != layer.Digest {
+		return "", fmt.Errorf("catalog %s: layer %s has digest %s", rc.Name, layer.Digest, layerDigest)
+	}
+
+	// Artifacts are packed without a top level directory.
+	return digest, extractStage(rc, "tar.gz", false, tmp, &archiveMeta{URL: rc.URL, Digest: digest})
+}
+
+// ociFetchError classifies an error talking to a registry.
+func ociFetchError(rc *RemoteConfig, op string, err error) error {
+	fe := &FetchError{Catalog: rc.Name, URL: rc.URL, Op: op, Err: err}
+	var re *registryError
+	var ne net.Error
+	switch {
+	case errors.As(err, &re) && (re.StatusCode == http.StatusUnauthorized || re.StatusCode == http.StatusForbidden):
+		fe.Kind = ErrAuth
+	case errors.As(err, &re) && re.StatusCode == http.StatusNotFound:
+		fe.Kind = ErrRefNotFound
+	case errors.As(err, &ne):
+		fe.Kind = ErrNetwork
+	}
+	return fe
+}
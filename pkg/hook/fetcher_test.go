@@ -0,0 +1,273 @@
+package hook
+
+import (
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/viper"
+)
+
+// testOrigin is a repository to clone catalogs from.
+type testOrigin struct {
+	t    *testing.T
+	dir  string
+	repo *git.Repository
+}
+
+func newTestOrigin(t *testing.T, dir string) *testOrigin {
+	t.Helper()
+	// go-git runs git-upload-pack to clone from local repositories.
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testOrigin{t: t, dir: dir, repo: repo}
+}
+
+// commit commits the given files and tags the commit.
+func (o *testOrigin) commit(tag string, files map[string]string) string {
+	o.t.Helper()
+	wt, err := o.repo.Worktree()
+	if err != nil {
+		o.t.Fatal(err)
+	}
+	for p, data := range files {
+		path := filepath.Join(o.dir, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			o.t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			o.t.Fatal(err)
+		}
+		if _, err := wt.Add(p); err != nil {
+			o.t.Fatal(err)
+		}
+	}
+	h, err := wt.Commit(tag, &git.CommitOptions{
+		Author: &object.Signature{Name: "hook", Email: "hook@example.com", When: time.Now()},
+	})
+	if err != nil {
+		o.t.Fatal(err)
+	}
+	if _, err := o.repo.CreateTag(tag, h, nil); err != nil {
+		o.t.Fatal(err)
+	}
+	return h.String()
+}
+
+func TestNativeFetcher(t *testing.T) {
+	d := testdirInit(t)
+	defer os.RemoveAll(d)
+
+	o := newTestOrigin(t, filepath.Join(d, "origin"))
+	v1 := o.commit("v1", map[string]string{
+		"github/push.yaml": "method: POST\n",
+		"README.md":        "hi",
+	})
+	viper.Set("catalog.remote", []*RemoteConfig{{Name: "foo", URL: o.dir}})
+	viper.Set("catalog.fetcher", "native")
+
+	hooks, err := NewFromPath("foo@github/push")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hooks[0].Method != "POST" {
+		t.Errorf("got method %s, want POST", hooks[0].Method)
+	}
+
+	v2 := o.commit("v2", map[string]string{"github/push.yaml": "method: PUT\n"})
+
+	// Revisions are fetched without changing the checkout.
+	for rev, method := range map[string]string{"v2": "PUT", "v1": "POST", v1[:7]: "POST"} {
+		hooks, err := NewFromPath("foo@github/push#" + rev)
+		if err != nil {
+			t.Errorf("NewFromPath(foo@github/push#%s): %v", rev, err)
+			continue
+		}
+		if hooks[0].Method != method {
+			t.Errorf("at %s: got method %s, want %s", rev, hooks[0].Method, method)
+		}
+	}
+	rc, err := GetRemoteConfig("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if commit, err := rc.Commit(); err != nil || commit != v1 {
+		t.Errorf("Commit() = (%s, %v), want %s", commit, err, v1)
+	}
+
+	c, err := rc.At("v2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	if err := WalkCatalog(c, "", func(p string) error {
+		got = append(got, p)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0] != "github/push.yaml" {
+		t.Errorf("WalkCatalog = %v", got)
+	}
+
+	if err := rc.Update(); err != nil {
+		t.Fatal(err)
+	}
+	if commit, err := rc.Commit(); err != nil || commit != v2 {
+		t.Errorf("Commit() after update = (%s, %v), want %s", commit, err, v2)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(rc.Path(), "github", "push.yaml"))
+	if err != nil || string(b) != "method: PUT\n" {
+		t.Errorf("checkout = (%q, %v)", b, err)
+	}
+
+	if _, err := NewFromPath("foo@github/push#v3"); !errors.Is(err, ErrRefNotFound) {
+		t.Errorf("got %v, want ErrRefNotFound", err)
+	}
+
+	// Clones check out the configured revision.
+	pinned := &RemoteConfig{Name: "pinned", URL: o.dir, Revision: "v1"}
+	if err := pinned.Clone(); err != nil {
+		t.Fatal(err)
+	}
+	if commit, err := pinned.Commit(); err != nil || commit != v1 {
+		t.Errorf("Commit() of pinned = (%s, %v), want %s", commit, err, v1)
+	}
+	missing := &RemoteConfig{Name: "missing", URL: o.dir, Revision: "nope"}
+	if err := missing.Clone(); !errors.Is(err, ErrRefNotFound) {
+		t.Errorf("got %v, want ErrRefNotFound", err)
+	}
+	if missing.isCached() {
+		t.Error("failed clone left a cache behind")
+	}
+}
+
+func TestFetchErrors(t *testing.T) {
+	d := testdirInit(t)
+	defer os.RemoveAll(d)
+
+	rc := &RemoteConfig{Name: "foo", URL: "https://example.com/foo"}
+	native := []struct {
+		err  error
+		kind error
+	}{
+		{transport.ErrAuthenticationRequired, ErrAuth},
+		{transport.ErrRepositoryNotFound, ErrRefNotFound},
+		{plumbing.NewUnexpectedError(&net.OpError{Op: "dial", Err: errors.New("connection refused")}), ErrNetwork},
+		{errors.New("boom"), nil},
+	}
+	for _, tc := range native {
+		err := nativeFetchError(rc, "fetch", tc.err)
+		for _, kind := range []error{ErrAuth, ErrRefNotFound, ErrNetwork} {
+			if got := errors.Is(err, kind); got != (kind == tc.kind) {
+				t.Errorf("errors.Is(%v, %v) = %t", err, kind, got)
+			}
+		}
+	}
+
+	stderr := map[string]error{
+		"fatal: Authentication failed for 'https://example.com/foo/'":                     ErrAuth,
+		"fatal: Remote branch v9 not found in upstream origin":                            ErrRefNotFound,
+		"fatal: unable to access 'https://example.com/foo/': Could not resolve host: foo": ErrNetwork,
+	}
+	for msg, kind := range stderr {
+		c := &commandSink{output: func(args []string) ([]byte, error) {
+			return nil, &exec.ExitError{Stderr: []byte(msg)}
+		}}
+		c.install()
+		_, err := ExecFetcher{}.Fetch(rc, "v9")
+		if !errors.Is(err, kind) {
+			t.Errorf("%s: got %v, want %v", msg, err, kind)
+		}
+		var fe *FetchError
+		if !errors.As(err, &fe) || fe.Catalog != "foo" || fe.Op != "fetch" {
+			t.Errorf("%s: got %#v", msg, err)
+		}
+	}
+
+	viper.Set("catalog.fetcher", "svn")
+	if err := rc.Clone(); err == nil {
+		t.Error("expected error for unknown fetcher")
+	}
+}
+
+// authFailingFetcher fails every clone and fetch with ErrAuth, like the native
+// fetcher does for private catalogs.
+type authFailingFetcher struct {
+	NativeFetcher
+}
+
+func (authFailingFetcher) Clone(rc *RemoteConfig) error {
+	return &FetchError{Catalog: rc.Name, URL: rc.URL, Op: "clone", Kind: ErrAuth, Err: errors.New("authentication required")}
+}
+
+func (authFailingFetcher) Fetch(rc *RemoteConfig, rev string) (string, error) {
+	return "", &FetchError{Catalog: rc.Name, URL: rc.URL, Op: "fetch", Kind: ErrAuth, Err: errors.New("authentication required")}
+}
+
+func TestExecFallbackFetcher(t *testing.T) {
+	d := testdirInit(t)
+	defer os.RemoveAll(d)
+	installed := gitInstalled
+	defer func() { gitInstalled = installed }()
+
+	// The native fetcher falls back to git only if it is installed.
+	rc := &RemoteConfig{Name: "foo", URL: "https://example.com/foo"}
+	viper.Set("catalog.fetcher", "native")
+	for _, git := range []bool{true, false} {
+		gitInstalled = func() bool { return git }
+		f, err := rc.fetcher()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := f.(execFallbackFetcher); ok != git {
+			t.Errorf("git installed: %t, got fetcher %T", git, f)
+		}
+	}
+
+	c := &commandSink{output: func(args []string) ([]byte, error) {
+		return []byte("abc123\n"), nil
+	}}
+	c.install()
+	f := execFallbackFetcher{authFailingFetcher{}}
+	if err := f.Clone(rc); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Fetch(rc, "v1"); err != nil {
+		t.Fatal(err)
+	}
+	gitdir := filepath.Join(rc.Path(), ".git")
+	want := [][]string{
+		{"git", "clone", "-q", rc.URL, rc.Path()},
+		{"git", "--git-dir", gitdir, "fetch", "-q", "origin", "v1"},
+		{"git", "--git-dir", gitdir, "rev-parse", "FETCH_HEAD"},
+	}
+	if diff := cmp.Diff(want, c.commands()); diff != "" {
+		t.Error(diff)
+	}
+
+	// Other errors aren't retried.
+	c.reset()
+	f = execFallbackFetcher{offlineFetcher{NativeFetcher{}}}
+	if err := f.Clone(rc); !errors.Is(err, ErrOffline) {
+		t.Errorf("got %v, want ErrOffline", err)
+	}
+	if len(c.commands()) != 0 {
+		t.Errorf("ran %v", c.commands())
+	}
+}
@@ -0,0 +1,338 @@
+package hook
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// HAR is an HTTP Archive, as described by
+// http://www.softwareishard.com/blog/har-12-spec/.
+// Only the fields relevant to hooks are included.
+type HAR struct {
+	Log *HARLog `json:"log"`
+}
+
+// HARLog is the root of the exported data.
+type HARLog struct {
+	Version string      `json:"version"`
+	Creator *HARCreator `json:"creator"`
+	Entries []*HAREntry `json:"entries"`
+}
+
+// HARCreator describes the application that created the log.
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// HAREntry is a single exported request and response.
+type HAREntry struct {
+	StartedDateTime time.Time    `json:"startedDateTime"`
+	Time            float64      `json:"time"`
+	Request         *HARRequest  `json:"request"`
+	Response        *HARResponse `json:"response"`
+	Cache           struct{}     `json:"cache"`
+	Timings         *HARTimings  `json:"timings"`
+}
+
+// HARRequest describes a performed request.
+type HARRequest struct {
+	Method      string          `json:"method"`
+	URL         string          `json:"url"`
+	HTTPVersion string          `json:"httpVersion"`
+	Cookies     []*HARNameValue `json:"cookies"`
+	Headers     []*HARNameValue `json:"headers"`
+	QueryString []*HARNameValue `json:"queryString"`
+	PostData    *HARPostData    `json:"postData,omitempty"`
+	HeadersSize int             `json:"headersSize"`
+	BodySize    int             `json:"bodySize"`
+}
+
+// HARResponse describes the response to a request.
+type HARResponse struct {
+	Status      int             `json:"status"`
+	StatusText  string          `json:"statusText"`
+	HTTPVersion string          `json:"httpVersion"`
+	Cookies     []*HARNameValue `json:"cookies"`
+	Headers     []*HARNameValue `json:"headers"`
+	Content     *HARContent     `json:"content"`
+	RedirectURL string          `json:"redirectURL"`
+	HeadersSize int             `json:"headersSize"`
+	BodySize    int             `json:"bodySize"`
+}
+
+// HARNameValue is a header, query parameter or cookie.
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARPostData describes a request body.
+type HARPostData struct {
+	MimeType string          `json:"mimeType"`
+	Text     string          `json:"text"`
+	Params   []*HARNameValue `json:"params,omitempty"`
+}
+
+// HARContent describes a response body.
+type HARContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// HARTimings describes the time spent in each phase of the request.
+type HARTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// NewHAR returns an empty HAR created by hook.
+func NewHAR() *HAR {
+	return &HAR{
+		Log: &HARLog{
+			Version: "1.2",
+			Creator: &HARCreator{
+				Name:    AppName,
+				Version: Version,
+			},
+			Entries: []*HAREntry{},
+		},
+	}
+}
+
+// Write writes the HAR as indented JSON.
+func (h *HAR) Write(w io.Writer) error {
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return e.Encode(h)
+}
+
+// harNameValues converts headers or query parameters into HAR name/value
+// pairs in a deterministic order.
+func harNameValues(m map[string][]string) []*HARNameValue {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	out := []*HARNameValue{}
+	for _, k := range keys {
+		for _, v := range m[k] {
+			out = append(out, &HARNameValue{Name: k, Value: v})
+		}
+	}
+	return out
+}
+
+// harHeaders converts request headers into HAR name/value pairs, skipping
+// headers computed by the client.
+func harHeaders(h http.Header) []*HARNameValue {
+	out := []*HARNameValue{}
+	for _, kv := range sortedHeaders(h) {
+		out = append(out, &HARNameValue{Name: kv[0], Value: kv[1]})
+	}
+	return out
+}
+
+// newHARRequest converts a built request and its body into a HAR request.
+func newHARRequest(r *http.Request, body []byte) *HARRequest {
+	out := &HARRequest{
+		Method:      r.Method,
+		URL:         r.URL.String(),
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []*HARNameValue{},
+		Headers:     harHeaders(r.Header),
+		QueryString: harNameValues(r.URL.Query()),
+		HeadersSize: -1,
+		BodySize:    len(body),
+	}
+	if len(body) > 0 {
+		out.PostData = &HARPostData{
+			MimeType: r.Header.Get("Content-Type"),
+			Text:     string(body),
+		}
+	}
+	return out
+}
+
+// newHARResponse converts a response and its body into a HAR response.
+func newHARResponse(res *http.Response, body []byte) *HARResponse {
+	content := &HARContent{
+		Size:     len(body),
+		MimeType: res.Header.Get("Content-Type"),
+	}
+	if utf8.Valid(body) {
+		content.Text = string(body)
+	} else {
+		content.Text = base64.StdEncoding.EncodeToString(body)
+		content.Encoding = "base64"
+	}
+	return &HARResponse{
+		Status:      res.StatusCode,
+		StatusText:  strings.TrimSpace(strings.TrimPrefix(res.Status, strconv.Itoa(res.StatusCode))),
+		HTTPVersion: res.Proto,
+		Cookies:     []*HARNameValue{},
+		Headers:     harNameValues(res.Header),
+		Content:     content,
+		RedirectURL: res.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    len(body),
+	}
+}
+
+// emptyHARResponse is used for entries that were not sent.
+func emptyHARResponse() *HARResponse {
+	return &HARResponse{
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []*HARNameValue{},
+		Headers:     []*HARNameValue{},
+		Content:     &HARContent{},
+		HeadersSize: -1,
+		BodySize:    -1,
+	}
+}
+
+// HARExporter exports hooks as an HTTP Archive.
+type HARExporter struct {
+	// Client, if set, is used to fire each hook so that entries include the
+	// response. Otherwise hooks are not sent and entries have an empty
+	// response.
+	Client *http.Client
+}
+
+// Export writes all hooks as entries of a single HAR.
+func (e HARExporter) Export(w io.Writer, target string, hooks []*Hook) error {
+	har := NewHAR()
+	for _, h := range hooks {
+		r, body, err := exportRequest(h, target)
+		if err != nil {
+			return err
+		}
+		entry := &HAREntry{
+			StartedDateTime: time.Now(),
+			Request:         newHARRequest(r, body),
+			Response:        emptyHARResponse(),
+			Timings:         &HARTimings{},
+		}
+		if e.Client != nil {
+			res, err := e.Client.Do(r)
+			if err != nil {
+				return err
+			}
+			wait := time.Since(entry.StartedDateTime)
+			b, err := ioutil.ReadAll(res.Body)
+			res.Body.Close()
+			if err != nil {
+				return err
+			}
+			entry.Response = newHARResponse(res, b)
+			entry.Timings.Wait = millis(wait)
+			entry.Timings.Receive = millis(time.Since(entry.StartedDateTime) - wait)
+			entry.Time = entry.Timings.Wait + entry.Timings.Receive
+		}
+		har.Log.Entries = append(har.Log.Entries, entry)
+	}
+	return har.Write(w)
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// HARFilter selects which HAR entries are imported.
+type HARFilter struct {
+	// URL, if set, must match the request URL.
+	URL *regexp.Regexp
+	// Method, if set, must equal the request method (case insensitive).
+	Method string
+}
+
+func (f HARFilter) match(r *HARRequest) bool {
+	if f.URL != nil && !f.URL.MatchString(r.URL) {
+		return false
+	}
+	if f.Method != "" && !strings.EqualFold(f.Method, r.Method) {
+		return false
+	}
+	return true
+}
+
+// NewFromHAR creates new Hooks from the requests of the HAR entries matching
+// filter. The entry start time is recorded as the hook's ReceivedAt.
+func NewFromHAR(r io.Reader, filter HARFilter, opts ...Option) ([]*Hook, error) {
+	har := new(HAR)
+	if err := json.NewDecoder(r).Decode(har); err != nil {
+		return nil, err
+	}
+	if har.Log == nil {
+		return nil, errors.New("invalid HAR: missing log")
+	}
+
+	res := []*Hook{}
+	for i, e := range har.Log.Entries {
+		if e.Request == nil || !filter.match(e.Request) {
+			continue
+		}
+		h, err := hookFromHARRequest(e.Request)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: %v", i, err)
+		}
+		h.ReceivedAt = e.StartedDateTime.UTC()
+		for _, o := range opts {
+			if err := o.Apply(h); err != nil {
+				return nil, fmt.Errorf("entry %d: %v", i, err)
+			}
+		}
+		res = append(res, h)
+	}
+	return res, nil
+}
+
+func hookFromHARRequest(r *HARRequest) (*Hook, error) {
+	u, err := url.Parse(r.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &Hook{Method: r.Method}
+	for _, kv := range r.Headers {
+		// Skip HTTP/2 pseudo-headers (e.g. :authority) recorded by browsers.
+		if strings.HasPrefix(kv.Name, ":") {
+			continue
+		}
+		if h.Headers == nil {
+			h.Headers = make(http.Header)
+		}
+		h.Headers.Add(kv.Name, kv.Value)
+	}
+	if q := u.Query(); len(q) > 0 {
+		h.Params = q
+	}
+	if r.PostData != nil {
+		h.Body = r.PostData.Text
+		if h.Body == "" && len(r.PostData.Params) > 0 {
+			form := url.Values{}
+			for _, p := range r.PostData.Params {
+				form.Add(p.Name, p.Value)
+			}
+			h.Body = form.Encode()
+		}
+	}
+	return h, nil
+}
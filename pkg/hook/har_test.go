@@ -0,0 +1,166 @@
+package hook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testHAR = `{
+  "log": {
+    "version": "1.2",
+    "creator": {"name": "devtools", "version": "1"},
+    "entries": [
+      {
+        "startedDateTime": "2019-12-25T08:30:00.000Z",
+        "request": {
+          "method": "POST",
+          "url": "https://example.com/webhooks/github?a=b",
+          "httpVersion": "HTTP/2",
+          "headers": [
+            {"name": ":authority", "value": "example.com"},
+            {"name": "content-type", "value": "application/json"},
+            {"name": "x-github-event", "value": "push"}
+          ],
+          "postData": {"mimeType": "application/json", "text": "{\"ref\":\"master\"}"}
+        },
+        "response": {"status": 200}
+      },
+      {
+        "startedDateTime": "2019-12-25T08:30:01.000Z",
+        "request": {
+          "method": "POST",
+          "url": "https://example.com/webhooks/twilio",
+          "headers": [],
+          "postData": {
+            "mimeType": "application/x-www-form-urlencoded",
+            "params": [{"name": "Body", "value": "hello world"}]
+          }
+        },
+        "response": {"status": 200}
+      },
+      {
+        "startedDateTime": "2019-12-25T08:30:02.000Z",
+        "request": {
+          "method": "GET",
+          "url": "https://example.com/webhooks/github",
+          "headers": []
+        },
+        "response": {"status": 200}
+      },
+      {
+        "startedDateTime": "2019-12-25T08:30:03.000Z",
+        "request": {
+          "method": "GET",
+          "url": "https://example.com/static/app.js",
+          "headers": []
+        },
+        "response": {"status": 200}
+      }
+    ]
+  }
+}`
+
+func TestNewFromHAR(t *testing.T) {
+	base := time.Date(2019, 12, 25, 8, 30, 0, 0, time.UTC)
+	github := &Hook{
+		Method: http.MethodPost,
+		Headers: http.Header{
+			"Content-Type":   {"application/json"},
+			"X-Github-Event": {"push"},
+		},
+		Body:       `{"ref":"master"}`,
+		Params:     url.Values{"a": {"b"}},
+		ReceivedAt: base,
+	}
+	twilio := &Hook{
+		Method:     http.MethodPost,
+		Body:       "Body=hello+world",
+		ReceivedAt: base.Add(time.Second),
+	}
+	githubGet := &Hook{
+		Method:     http.MethodGet,
+		ReceivedAt: base.Add(2 * time.Second),
+	}
+
+	testcases := []struct {
+		name   string
+		filter HARFilter
+		want   []*Hook
+	}{
+		{
+			name:   "url and method",
+			filter: HARFilter{URL: regexp.MustCompile("/webhooks/"), Method: "post"},
+			want:   []*Hook{github, twilio},
+		},
+		{
+			name:   "url",
+			filter: HARFilter{URL: regexp.MustCompile("/webhooks/github")},
+			want:   []*Hook{github, githubGet},
+		},
+		{
+			name: "all",
+			want: []*Hook{github, twilio, githubGet, {Method: http.MethodGet, ReceivedAt: base.Add(3 * time.Second)}},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := NewFromHAR(strings.NewReader(testHAR), tc.filter)
+			if err != nil {
+				t.Fatalf("NewFromHAR: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+
+	if _, err := NewFromHAR(strings.NewReader(`{}`), HARFilter{}); err == nil {
+		t.Error("expected error for HAR without log")
+	}
+}
+
+func TestHARExporterFire(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("thanks"))
+	}))
+	defer srv.Close()
+
+	b := new(bytes.Buffer)
+	e := HARExporter{Client: srv.Client()}
+	if err := e.Export(b, srv.URL, []*Hook{{Method: http.MethodPost, Body: "ping"}}); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	har := new(HAR)
+	if err := json.Unmarshal(b.Bytes(), har); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	res := har.Log.Entries[0].Response
+	if res.Status != http.StatusAccepted || res.StatusText != "Accepted" {
+		t.Errorf("got status %d %s, want 202 Accepted", res.Status, res.StatusText)
+	}
+	want := &HARContent{Size: 6, MimeType: "text/plain", Text: "thanks"}
+	if diff := cmp.Diff(want, res.Content); diff != "" {
+		t.Error(diff)
+	}
+
+	// Exported entries can be imported again.
+	hooks, err := NewFromHAR(b, HARFilter{})
+	if err != nil {
+		t.Fatalf("NewFromHAR: %v", err)
+	}
+	if len(hooks) != 1 || hooks[0].Body != "ping" {
+		t.Errorf("round trip: got %+v", hooks)
+	}
+}
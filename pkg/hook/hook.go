@@ -1,48 +1,45 @@
 package hook
 
 import (
+	"bufio"
 	"bytes"
-	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"net/url"
 	"strings"
-
-	"gopkg.in/yaml.v2"
+	"time"
 )
 
-// Hook represents a single hook configuration.
+// Hook represents a single hook configuration. Hooks are serialized as
+// versioned documents, see Dump and New.
 type Hook struct {
-	Method  string      `yaml:"method"`
-	Headers http.Header `yaml:"headers,omitempty"`
-	Body    string      `yaml:"body,omitempty"`
-	Params  url.Values  `yaml:"params,omitempty"`
+	Name        string
+	Description string
 
-	Transform map[TransformStrategy][]string `yaml:",omitempty"`
-}
+	Method  string
+	Headers http.Header
+	Body    string
+	Params  url.Values
 
-type jsonMarshal struct {
-	Method    string                         `yaml:"method"`
-	Headers   http.Header                    `yaml:"headers,omitempty"`
-	Body      jsonBody                       `yaml:"body,omitempty"`
-	Params    url.Values                     `yaml:"params,omitempty"`
-	Transform map[TransformStrategy][]string `yaml:"transform,omitempty"`
-}
+	Transform map[TransformStrategy][]string
+	// Template renders the body, header values and param values as
+	// templates, which is implied by Vars. Other hooks are sent verbatim.
+	Template bool
+	Vars     map[string]string
 
-// Implement a custom marshaller to pretty print payload body. This also gets
-// around line length restrictions of the yaml package in most cases.
-type jsonBody string
+	// CloudEvents is the content mode of hooks that are CloudEvents. The id
+	// and time attributes are populated on fire if missing.
+	CloudEvents CloudEventsMode
+	// Envelope wraps the body in a cloud provider's envelope on fire.
+	Envelope *Envelope
+	// Signing signs the body with an HMAC on fire.
+	Signing *Signing
 
-func (s jsonBody) MarshalYAML() (interface{}, error) {
-	buf := new(bytes.Buffer)
-	if err := json.Indent(buf, []byte(string(s)), "", "  "); err != nil {
-		log.Println("error indenting payload:", err, s)
-		return nil, err
-	}
-	return buf.String(), nil
+	// ReceivedAt is when the hook was recorded, used to replay hooks with
+	// their original timing.
+	ReceivedAt time.Time
 }
 
 // Option allows for optional modifications to be made onto the new hook.
@@ -74,86 +71,164 @@ func NewFromRequest(r *http.Request, opts ...Option) (*Hook, error) {
 	return h, nil
 }
 
-// NewFromPath creates a new Hook from the given path.
-func NewFromPath(path string) ([]*Hook, error) {
-	// Default to LocalCatalog.
-	var cfg Catalog = LocalCatalog{}
-
-	catalog, path := ParsePath(path)
-	if catalog != "" {
-		// Remote catalog.
-		var err error
-		cfg, err = GetRemoteConfig(catalog)
-		if err != nil {
+// NewFromHTTP creates new Hooks from one or more raw HTTP/1.1 request
+// messages, such as those captured with nc or tcpdump.
+func NewFromHTTP(r io.Reader, opts ...Option) ([]*Hook, error) {
+	res := []*Hook{}
+	br := bufio.NewReader(r)
+	for {
+		// Captures commonly include blank lines between (or after) requests.
+		if err := skipSpace(br); err == io.EOF {
+			return res, nil
+		} else if err != nil {
 			return nil, err
 		}
+
+		req, err := http.ReadRequest(br)
+		if err != nil {
+			return nil, fmt.Errorf("request %d: %v", len(res)+1, err)
+		}
+		h, err := NewFromRequest(req, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("request %d: %v", len(res)+1, err)
+		}
+		res = append(res, h)
+	}
+}
+
+// skipSpace discards leading whitespace from r.
+func skipSpace(r *bufio.Reader) error {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		if !strings.ContainsRune(" \t\r\n", rune(b)) {
+			return r.UnreadByte()
+		}
+	}
+}
+
+// NewFromPath creates a new Hook from the given path, which may refer to a
+// catalog as <catalog>@<path>. The defaults of the catalog's manifest are
+// applied to hooks from catalogs.
+func NewFromPath(path string) ([]*Hook, error) {
+	c, path, err := GetCatalog(path)
+	if err != nil {
+		return nil, err
 	}
+	return newFromCatalog(c, path)
+}
 
-	f, err := cfg.Open(path)
+// newFromCatalog creates new Hooks from the file at path in a catalog,
+// applying the defaults of the catalog's manifest.
+func newFromCatalog(c Catalog, path string) ([]*Hook, error) {
+	f, path, err := OpenHook(c, path)
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
-	return New(f)
+	hooks, err := New(f)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := c.(LocalCatalog); ok {
+		return hooks, nil
+	}
+	m, err := ReadManifest(c)
+	if err != nil || m == nil {
+		return hooks, err
+	}
+	if err := m.CheckVersion(); err != nil {
+		return nil, err
+	}
+	m.Apply(path, hooks)
+	return hooks, nil
 }
 
-// New creates a new Hook from the given bytestring.
+// New creates new Hooks from a multidoc YAML file. Documents are decoded
+// strictly according to their apiVersion, see DocumentError for the errors
+// returned.
 func New(r io.Reader) ([]*Hook, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
 	res := []*Hook{}
-	d := yaml.NewDecoder(r)
-
-	var err error
-	for err == nil {
-		h := new(Hook)
-		err = d.Decode(h)
-		if err == nil {
-			res = append(res, h)
+	for _, d := range splitDocuments(b) {
+		if d.empty() {
+			continue
 		}
-	}
-	if err != io.EOF {
-		return nil, err
+		h, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, h)
 	}
 	return res, nil
 }
 
-// Dump TODO(eddiezane): Is this the right method?
-func (h *Hook) Dump() ([]byte, error) {
-	switch h.Headers.Get("Content-Type") {
-	case "application/json":
-		return yaml.Marshal(&jsonMarshal{
-			Method:    h.Method,
-			Headers:   h.Headers,
-			Body:      jsonBody(h.Body),
-			Params:    h.Params,
-			Transform: h.Transform,
-		})
-	default:
-		return yaml.Marshal(h)
+// DumpAll serializes hooks into a single multidoc YAML file, starting with a
+// schema modeline.
+func DumpAll(hooks []*Hook) ([]byte, error) {
+	buf := bytes.NewBufferString(SchemaModeline)
+	for i, h := range hooks {
+		b, err := h.dump()
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+		buf.Write(b)
 	}
+	return buf.Bytes(), nil
 }
 
 // Fire sends an HTTP request to the given target.
 func (h *Hook) Fire(target string) (*http.Response, error) {
+	return h.FireWithClient(http.DefaultClient, target)
+}
+
+// FireWithClient sends an HTTP request to the given target using client.
+func (h *Hook) FireWithClient(client *http.Client, target string) (*http.Response, error) {
 	r, err := h.toRequest(target)
 	if err != nil {
 		return nil, err
 	}
-	return http.DefaultClient.Do(r)
+	return client.Do(r)
 }
 
-// toRequest converts the hook into a HTTP request.
+// toRequest converts the hook into a HTTP request. The hook itself is not
+// modified, so the same hook may be converted multiple times (and
+// concurrently).
 func (h *Hook) toRequest(target string) (*http.Request, error) {
-	for t, paths := range h.Transform {
-		fn, ok := Transformers[t]
-		if !ok {
-			return nil, fmt.Errorf("unknown transformer %v", t)
+	rh, err := h.render()
+	if err != nil {
+		return nil, err
+	}
+	return rh.buildRequest(target)
+}
+
+// buildRequest converts an already rendered hook into a HTTP request,
+// applying transforms to the body.
+func (h *Hook) buildRequest(target string) (*http.Request, error) {
+	body, err := h.encodedBody()
+	if err != nil {
+		return nil, err
+	}
+	body, headers, err := h.wrap(body)
+	if err != nil {
+		return nil, err
+	}
+	if h.Signing != nil && !h.Signing.skip() {
+		if headers == nil {
+			headers = make(http.Header)
 		}
-		for _, path := range paths {
-			var err error
-			h.Body, err = fn.Encode(h.Body, path)
-			if err != nil {
-				return nil, err
-			}
+		if err := h.Signing.Sign(body, headers); err != nil {
+			return nil, err
 		}
 	}
 
@@ -162,14 +237,33 @@ func (h *Hook) toRequest(target string) (*http.Request, error) {
 		return nil, err
 	}
 
-	r.Header = h.Headers
+	r.Header = headers
 
 	r.URL.RawQuery = h.Params.Encode()
 
-	if h.Body != "" {
-		reader := strings.NewReader(h.Body)
+	if body != "" {
+		reader := strings.NewReader(body)
 		r.Body = ioutil.NopCloser(reader)
 	}
 
 	return r, nil
 }
+
+// encodedBody returns the body with all transforms applied.
+func (h *Hook) encodedBody() (string, error) {
+	body := h.Body
+	for t, paths := range h.Transform {
+		fn, ok := Transformers[t]
+		if !ok {
+			return "", fmt.Errorf("unknown transformer %v", t)
+		}
+		for _, path := range paths {
+			var err error
+			body, err = fn.Encode(body, path)
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+	return body, nil
+}
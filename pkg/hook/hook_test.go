@@ -259,3 +259,75 @@ func TestNewFromPath(t *testing.T) {
 		})
 	}
 }
+
+func TestNewFromHTTP(t *testing.T) {
+	// Requests as captured by nc, with bare newlines and trailing whitespace.
+	raw := `POST /?query=value HTTP/1.1
+Host: localhost:8080
+Accept: */*
+Content-Length: 15
+Content-Type: application/json
+User-Agent: curl/7.66.0
+
+{"foo": "YmFy"}
+
+GET / HTTP/1.1
+Host: localhost:8080
+
+`
+	got, err := NewFromHTTP(strings.NewReader(raw), DecodeOption(Base64Transformer{}, "foo"))
+	if err != nil {
+		t.Fatalf("NewFromHTTP: %v", err)
+	}
+
+	want := []*Hook{
+		{
+			Method: http.MethodPost,
+			Headers: http.Header{
+				"Accept":         {"*/*"},
+				"Content-Length": {"15"},
+				"Content-Type":   {"application/json"},
+				"User-Agent":     {"curl/7.66.0"},
+			},
+			Body:      `{"foo": "bar"}`,
+			Params:    url.Values{"query": {"value"}},
+			Transform: map[TransformStrategy][]string{TransformBase64: {"foo"}},
+		},
+		{
+			Method:  http.MethodGet,
+			Headers: http.Header{},
+			Params:  url.Values{},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+
+	if _, err := NewFromHTTP(strings.NewReader("not http\n\n")); err == nil {
+		t.Error("expected error for invalid request")
+	}
+}
+
+func TestFireLiteralTemplate(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		got = string(b)
+	}))
+	defer srv.Close()
+
+	// Hooks that don't opt into templates, such as recorded ones, are sent
+	// verbatim.
+	hooks, err := New(strings.NewReader("apiVersion: hook/v1\nkind: Hook\nmethod: POST\nbody: Hi {{name}}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := hooks[0].FireWithClient(srv.Client(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got != "Hi {{name}}" {
+		t.Errorf("got body %q, want %q", got, "Hi {{name}}")
+	}
+}
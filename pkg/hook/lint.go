@@ -0,0 +1,319 @@
+package hook
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"gopkg.in/yaml.v2"
+)
+
+// Diagnostic is a problem found in a hook file.
+type Diagnostic struct {
+	Path string
+	// Line is the line of the file the problem is on, starting at 1.
+	Line int
+	Msg  string
+}
+
+func (d *Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d: %s", d.Path, d.Line, d.Msg)
+}
+
+var (
+	// methods are the HTTP methods hooks can use.
+	methods = map[string]bool{
+		http.MethodGet:     true,
+		http.MethodHead:    true,
+		http.MethodPost:    true,
+		http.MethodPut:     true,
+		http.MethodPatch:   true,
+		http.MethodDelete:  true,
+		http.MethodConnect: true,
+		http.MethodOptions: true,
+		http.MethodTrace:   true,
+	}
+
+	// secretHeaders are headers whose values are credentials.
+	secretHeaders = regexp.MustCompile(`(?i)^(authorization|proxy-authorization|cookie|x-api-key|.*-(token|secret|password|api-key))$`)
+	// secretVars are variable names whose values are credentials.
+	secretVars = regexp.MustCompile(`(?i)(secret|token|password|passwd|api_?key|private_?key)`)
+	// secretValues match common credential formats.
+	secretValues = regexp.MustCompile(`sk_live_[0-9A-Za-z]{10,}|rk_live_[0-9A-Za-z]{10,}|AKIA[0-9A-Z]{16}|gh[pousr]_[0-9A-Za-z]{36}|xox[abprs]-[0-9A-Za-z-]{10,}|-----BEGIN [A-Z ]*PRIVATE KEY-----`)
+)
+
+// Lint validates every document of the hook file read from r. path is only
+// used for diagnostics.
+func Lint(path string, r io.Reader) ([]*Diagnostic, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var diags []*Diagnostic
+	add := func(line int, format string, args ...interface{}) {
+		diags = append(diags, &Diagnostic{Path: path, Line: line, Msg: fmt.Sprintf(format, args...)})
+	}
+
+	for _, d := range splitDocuments(b) {
+		if d.empty() {
+			continue
+		}
+		h, err := d.decode()
+		if ok, err := addDocumentErrors(err, add); err != nil {
+			return nil, err
+		} else if ok {
+			continue
+		}
+
+		if v, _ := d.version(); v.APIVersion == "" {
+			add(d.Line, "missing apiVersion, run hook migrate")
+		}
+		lintHook(d, h, add)
+	}
+	sort.SliceStable(diags, func(i, j int) bool {
+		return diags[i].Line < diags[j].Line
+	})
+	return diags, nil
+}
+
+// addDocumentErrors adds the errors of decoding a document as diagnostics.
+// It reports whether there were any, or returns err if it isn't a
+// DocumentError.
+func addDocumentErrors(err error, add func(line int, format string, args ...interface{})) (bool, error) {
+	switch err := err.(type) {
+	case nil:
+		return false, nil
+	case *DocumentError:
+		add(err.Line, "%s", err.Msg)
+	case DocumentErrors:
+		for _, e := range err {
+			add(e.Line, "%s", e.Msg)
+		}
+	default:
+		return false, err
+	}
+	return true, nil
+}
+
+// LintManifest validates the catalog manifest read from r. path is only used
+// for diagnostics.
+func LintManifest(path string, r io.Reader) ([]*Diagnostic, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var diags []*Diagnostic
+	add := func(line int, format string, args ...interface{}) {
+		diags = append(diags, &Diagnostic{Path: path, Line: line, Msg: fmt.Sprintf(format, args...)})
+	}
+
+	d := &document{Line: 1, Data: b}
+	m := &Manifest{}
+	if err := yaml.UnmarshalStrict(b, m); err != nil {
+		if _, err := addDocumentErrors(d.errorf(err), add); err != nil {
+			return nil, err
+		}
+		return diags, nil
+	}
+
+	if _, ok := parseVersion(m.MinHookVersion); m.MinHookVersion != "" && !ok {
+		add(d.keyLine("minHookVersion"), "invalid minHookVersion %q", m.MinHookVersion)
+	}
+	for k, v := range m.Vars {
+		if (v != "" && secretVars.MatchString(k)) || secretValues.MatchString(v) {
+			add(d.keyLine(k), "var %s looks like a secret, set it when firing instead", k)
+		}
+	}
+	dirs := make([]string, 0, len(m.Signing))
+	for dir := range m.Signing {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		lintSigning(d.keyLine(dir), m.Signing[dir], add)
+	}
+	sort.SliceStable(diags, func(i, j int) bool {
+		return diags[i].Line < diags[j].Line
+	})
+	return diags, nil
+}
+
+// lintSigning checks signing settings starting at line.
+func lintSigning(line int, s *Signing, add func(line int, format string, args ...interface{})) {
+	if s == nil {
+		add(line, "signing is empty")
+		return
+	}
+	for _, err := range s.problems() {
+		add(line, "%v", err)
+	}
+	if s.Secret == "" {
+		add(line, "signing secret is required")
+	} else if !isTemplate(s.Secret) {
+		add(line, "signing secret of %s is not a template, use a template variable", s.Header)
+	}
+}
+
+func lintHook(d *document, h *Hook, add func(line int, format string, args ...interface{})) {
+	if !methods[h.Method] {
+		add(d.keyLine("method"), "unknown method %q", h.Method)
+	}
+
+	for k, values := range h.Headers {
+		for _, v := range values {
+			if v != "" && !isTemplate(v) && secretHeaders.MatchString(k) {
+				add(d.keyLine(k), "header %s looks like a secret, use a template variable", k)
+			} else if secretValues.MatchString(v) {
+				add(d.keyLine(k), "header %s contains a secret", k)
+			}
+		}
+	}
+	for k, values := range h.Params {
+		for _, v := range values {
+			if secretValues.MatchString(v) {
+				add(d.keyLine("params"), "param %s contains a secret", k)
+			}
+		}
+	}
+	for k, v := range h.Vars {
+		if (v != "" && secretVars.MatchString(k)) || secretValues.MatchString(v) {
+			add(d.keyLine("vars"), "var %s looks like a secret, set it when firing instead", k)
+		}
+	}
+	if secretValues.MatchString(h.Body) {
+		add(d.keyLine("body"), "body contains a secret")
+	}
+
+	if h.CloudEvents != "" && h.CloudEvents != CloudEventsBinary && h.CloudEvents != CloudEventsStructured {
+		add(d.keyLine("cloudevents"), "unknown CloudEvents mode %q", h.CloudEvents)
+	}
+	if h.Envelope != nil {
+		if _, err := GetEnvelope(h.Envelope.Type); err != nil {
+			add(d.keyLine("envelope"), "%v", err)
+		}
+	}
+
+	if h.Signing != nil {
+		lintSigning(d.keyLine("signing"), h.Signing, add)
+		if h.Signing.Header != "" && h.Headers.Get(h.Signing.Header) != "" {
+			add(d.keyLine(h.Signing.Header), "header %s is replaced by the signature", h.Signing.Header)
+		}
+	}
+
+	if !h.templated() {
+		values := []string{h.Body}
+		for _, v := range h.Headers {
+			values = append(values, v...)
+		}
+		for _, v := range h.Params {
+			values = append(values, v...)
+		}
+		for _, v := range values {
+			if isHookTemplate(v) {
+				add(d.Line, "hook contains templates but isn't rendered, set template: true or vars")
+				break
+			}
+		}
+	}
+
+	// The remaining checks need the rendered hook.
+	rh, err := h.render()
+	if err != nil {
+		add(d.Line, "%v", err)
+		return
+	}
+
+	if rh.Body != "" && isJSONContentType(rh.Headers.Get("Content-Type")) && !json.Valid([]byte(rh.Body)) {
+		add(d.keyLine("body"), "body is not valid JSON")
+	}
+
+	strategies := make([]string, 0, len(h.Transform))
+	for t := range h.Transform {
+		strategies = append(strategies, string(t))
+	}
+	sort.Strings(strategies)
+	for _, t := range strategies {
+		if _, ok := Transformers[TransformStrategy(t)]; !ok {
+			add(d.keyLine("transform"), "unknown transformer %s", t)
+			continue
+		}
+		for _, p := range h.Transform[TransformStrategy(t)] {
+			if !gjson.Get(rh.Body, p).Exists() {
+				add(d.keyLine("transform"), "%s transform path %s not found in body", t, p)
+			}
+		}
+	}
+}
+
+// keyLine returns the line of the first line of the document that starts
+// with key, ignoring indentation, or the first line of the document.
+func (d *document) keyLine(key string) int {
+	s := bufio.NewScanner(bytes.NewReader(d.Data))
+	s.Buffer(nil, len(d.Data)+1)
+	for n := 0; s.Scan(); n++ {
+		t := strings.TrimSpace(s.Text())
+		if strings.HasPrefix(t, key+":") || strings.HasPrefix(t, `"`+key+`":`) {
+			return d.Line + n
+		}
+	}
+	return d.Line
+}
+
+// LintPath lints a hook file, or every hook file below a directory. Paths of
+// the form <catalog>@<path> lint the catalog's files.
+func LintPath(path string) ([]*Diagnostic, error) {
+	c, root, err := GetCatalog(path)
+	if err != nil {
+		return nil, err
+	}
+	prefix := ""
+	if catalog, _ := ParsePath(path); catalog != "" {
+		prefix = catalog + "@"
+		if catalog == DefaultCatalog.Name {
+			prefix = "@"
+		}
+	}
+
+	var diags []*Diagnostic
+	if fi, err := c.Stat(root); err == nil && fi.IsDir() {
+		// Lint the manifest of the catalog being walked.
+		p := strings.TrimSuffix(root, "/") + "/" + ManifestFile
+		if root == "" {
+			p = ManifestFile
+		}
+		if _, ok := c.(LocalCatalog); ok || root == "" {
+			if f, err := c.Open(p); err == nil {
+				d, err := LintManifest(prefix+p, f)
+				f.Close()
+				if err != nil {
+					return nil, err
+				}
+				diags = append(diags, d...)
+			}
+		}
+	}
+	err = WalkCatalog(c, root, func(p string) error {
+		f, err := c.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		d, err := Lint(prefix+p, f)
+		if err != nil {
+			return err
+		}
+		diags = append(diags, d...)
+		return nil
+	})
+	return diags, err
+}
@@ -0,0 +1,187 @@
+package hook
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLint(t *testing.T) {
+	in := `apiVersion: hook/v1
+kind: Hook
+method: POST
+headers:
+  Content-Type:
+  - application/json
+body: '{"a": "{{ .a }}"'
+vars:
+  a: b
+---
+apiVersion: hook/v1
+kind: Hook
+method: FETCH
+headers:
+  Authorization:
+  - Bearer abc
+  X-Api-Key:
+  - "{{ .key }}"
+body: '{"data": "e30="}'
+transform:
+  base64:
+  - data
+  - missing
+vars:
+  key: ""
+  stripe_secret: sk_live_0123456789abcdef
+---
+method: GET
+params:
+  a:
+  - "{{ .nope }}"
+template: true
+---
+apiVersion: hook/v1
+kind: Hook
+method: GET
+bdoy: typo
+---
+apiVersion: hook/v1
+kind: Hook
+method: POST
+headers:
+  X-Request-Id:
+  - "{{ uuid }}"
+body: Hi {{name}}
+---
+apiVersion: hook/v1
+kind: Hook
+method: POST
+signing:
+  algorithm: md5
+  encoding: base32
+---
+apiVersion: hook/v1
+kind: Hook
+method: POST
+headers:
+  X-Signature:
+  - abc
+signing:
+  header: X-Signature
+  secret: '{{ env "SECRET" }}'
+`
+	got, err := Lint("hooks.yaml", strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("Lint: %v", err)
+	}
+
+	var lines []string
+	for _, d := range got {
+		lines = append(lines, d.String())
+	}
+	want := []string{
+		"hooks.yaml:7: body is not valid JSON",
+		"hooks.yaml:13: unknown method \"FETCH\"",
+		"hooks.yaml:15: header Authorization looks like a secret, use a template variable",
+		"hooks.yaml:20: base64 transform path missing not found in body",
+		"hooks.yaml:24: var stripe_secret looks like a secret, set it when firing instead",
+		"hooks.yaml:28: missing apiVersion, run hook migrate",
+		"hooks.yaml:28: param a: template: :1:3: executing \"\" at <.nope>: map has no entry for key \"nope\"",
+		"hooks.yaml:37: unknown field bdoy",
+		"hooks.yaml:39: hook contains templates but isn't rendered, set template: true or vars",
+		"hooks.yaml:50: signing header is required",
+		`hooks.yaml:50: unknown signing algorithm "md5", expected one of: sha1, sha256, sha512`,
+		`hooks.yaml:50: unknown signing encoding "base32", expected one of: base64, hex`,
+		"hooks.yaml:50: signing secret is required",
+		"hooks.yaml:58: header X-Signature is replaced by the signature",
+	}
+	if diff := cmp.Diff(want, lines); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestLintPath(t *testing.T) {
+	d, err := ioutil.TempDir("", "hook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d)
+
+	files := map[string]string{
+		"ok.yaml":                            "apiVersion: hook/v1\nkind: Hook\nmethod: GET\n",
+		"bad.yml":                            "apiVersion: hook/v1\nkind: Hook\nmethod: nope\n",
+		"README.md":                          "not a hook",
+		ManifestFile:                         "signing:\n  github:\n    header: X-Hub-Signature-256\n    secret: abc\n",
+		filepath.Join(".git", "config.yaml"): "ignored: true\n",
+	}
+	for path, data := range files {
+		if err := os.MkdirAll(filepath.Join(d, filepath.Dir(path)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(d, path), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := LintPath(d)
+	if err != nil {
+		t.Fatalf("LintPath: %v", err)
+	}
+	want := []*Diagnostic{
+		{Path: filepath.Join(d, ManifestFile), Line: 2, Msg: "signing secret of X-Hub-Signature-256 is not a template, use a template variable"},
+		{Path: filepath.Join(d, "bad.yml"), Line: 3, Msg: `unknown method "nope"`},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+
+	// Extensions can be omitted.
+	got, err = LintPath(filepath.Join(d, "ok"))
+	if err != nil || len(got) != 0 {
+		t.Errorf("LintPath(ok): got %v, %v", got, err)
+	}
+}
+
+func TestLintManifest(t *testing.T) {
+	in := `name: test
+minHookVersion: latest
+vars:
+  api_token: abc
+signing:
+  github:
+    header: X-Hub-Signature-256
+    algorithm: md5
+    secret: '{{ env "SECRET" }}'
+  stripe:
+    header: Stripe-Signature
+`
+	got, err := LintManifest(ManifestFile, strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("LintManifest: %v", err)
+	}
+	var lines []string
+	for _, d := range got {
+		lines = append(lines, d.String())
+	}
+	want := []string{
+		`catalog.yaml:2: invalid minHookVersion "latest"`,
+		"catalog.yaml:4: var api_token looks like a secret, set it when firing instead",
+		`catalog.yaml:6: unknown signing algorithm "md5", expected one of: sha1, sha256, sha512`,
+		"catalog.yaml:10: signing secret is required",
+	}
+	if diff := cmp.Diff(want, lines); diff != "" {
+		t.Error(diff)
+	}
+
+	got, err = LintManifest(ManifestFile, strings.NewReader("nmae: typo\n"))
+	if err != nil {
+		t.Fatalf("LintManifest: %v", err)
+	}
+	if len(got) != 1 || got[0].String() != "catalog.yaml:1: unknown field nmae" {
+		t.Errorf("got %v", got)
+	}
+}
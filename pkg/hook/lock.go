@@ -0,0 +1,203 @@
+package hook
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+// LockfileName is the name of the file that pins the catalogs used by a
+// project to commits. It is looked up in the working directory and its
+// parents.
+const LockfileName = "hook.lock"
+
+const lockfileHeader = "# This file is generated by hook catalog lock. Do not edit it by hand.\n"
+
+// Lockfile records the commit of each catalog used by a project, so that
+// everyone fires the same hooks.
+type Lockfile struct {
+	Catalogs map[string]*LockedCatalog `yaml:"catalogs"`
+}
+
+// LockedCatalog is the locked state of a single catalog.
+type LockedCatalog struct {
+	URL    string `yaml:"url"`
+	Commit string `yaml:"commit"`
+}
+
+// FindLockfile returns the path of the lockfile in the working directory or
+// the closest parent that has one, or "" if there is none.
+func FindLockfile() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for {
+		p := filepath.Join(dir, LockfileName)
+		if _, err := os.Stat(p); err == nil {
+			return p, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// ReadLockfile reads the lockfile at path.
+func ReadLockfile(path string) (*Lockfile, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	l := &Lockfile{}
+	if err := yaml.UnmarshalStrict(b, l); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if l.Catalogs == nil {
+		l.Catalogs = make(map[string]*LockedCatalog)
+	}
+	return l, nil
+}
+
+// LoadLockfile finds and reads the lockfile of the project. It returns a nil
+// Lockfile if there is none.
+func LoadLockfile() (*Lockfile, string, error) {
+	path, err := FindLockfile()
+	if err != nil || path == "" {
+		return nil, "", err
+	}
+	l, err := ReadLockfile(path)
+	return l, path, err
+}
+
+// Write writes the lockfile to path.
+func (l *Lockfile) Write(path string) error {
+	b, err := yaml.Marshal(l)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append([]byte(lockfileHeader), b...), 0644)
+}
+
+// Lock records the commits checked out in the caches of the named catalogs,
+// or of every catalog if there are no names. Catalogs that aren't cached
+// yet are cloned.
+func (l *Lockfile) Lock(rcs RemoteConfigSet, names ...string) error {
+	if l.Catalogs == nil {
+		l.Catalogs = make(map[string]*LockedCatalog)
+	}
+	if len(names) == 0 {
+		for n := range rcs {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+	}
+	for _, n := range names {
+		rc, err := rcs.Get(n)
+		if err != nil {
+			return err
+		}
+		commit, err := rc.Commit()
+		if err != nil {
+			return err
+		}
+		l.Catalogs[n] = &LockedCatalog{URL: rc.URL, Commit: commit}
+	}
+	return nil
+}
+
+// Check returns the differences between the lockfile and the configured
+// catalogs and their caches.
+func (l *Lockfile) Check(rcs RemoteConfigSet) ([]string, error) {
+	names := make([]string, 0, len(l.Catalogs))
+	for n := range l.Catalogs {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	var problems []string
+	for _, n := range names {
+		lc := l.Catalogs[n]
+		rc, ok := rcs[n]
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("catalog %s is locked but not configured", n))
+			continue
+		case rc.URL != lc.URL:
+			problems = append(problems, fmt.Sprintf("catalog %s is locked to %s but configured as %s", n, lc.URL, rc.URL))
+			continue
+		case !rc.isCached():
+			problems = append(problems, fmt.Sprintf("catalog %s is not cached", n))
+			continue
+		}
+		commit, err := rc.Commit()
+		if err != nil {
+			return nil, err
+		}
+		if commit != lc.Commit {
+			problems = append(problems, fmt.Sprintf("catalog %s is at %s, locked at %s", n, commit, lc.Commit))
+		}
+	}
+	return problems, nil
+}
+
+// locked returns the locked state of a catalog, if the lockfile has one.
+func (l *Lockfile) locked(name string) (*LockedCatalog, bool) {
+	if l == nil {
+		return nil, false
+	}
+	lc, ok := l.Catalogs[name]
+	return lc, ok
+}
+
+// checkSnapshot returns an error if the catalog is locked to a commit other
+// than the one its embedded snapshot was taken at.
+func (l *Lockfile) checkSnapshot(rc *RemoteConfig, s *snapshotCatalog) error {
+	lc, ok := l.locked(rc.Name)
+	if !ok || lc.Commit == s.Revision {
+		return nil
+	}
+	return fmt.Errorf("catalog %s is locked to %s, but isn't cached and its embedded snapshot is of another commit, run hook catalog update", rc.Name, lc.Commit)
+}
+
+// catalog returns the catalog as locked, or rc itself if it isn't locked.
+func (l *Lockfile) catalog(rc *RemoteConfig) (Catalog, error) {
+	lc, ok := l.locked(rc.Name)
+	if !ok {
+		return rc, nil
+	}
+	if lc.URL != rc.URL {
+		return nil, fmt.Errorf("catalog %s is locked to %s but configured as %s", rc.Name, lc.URL, rc.URL)
+	}
+	return rc.At(lc.Commit)
+}
+
+// remoteCatalog returns the catalog of rc that hooks are read from without a
+// pinned revision: the commit locked in the lockfile, or else the cache or
+// the embedded snapshot.
+func (l *Lockfile) remoteCatalog(rc *RemoteConfig) (Catalog, *catalogSource, error) {
+	c, err := rc.cachedOrSnapshot()
+	if err != nil {
+		return nil, nil, err
+	}
+	if s, ok := c.(*snapshotCatalog); ok {
+		if err := l.checkSnapshot(rc, s); err != nil {
+			return nil, nil, err
+		}
+		return s, &catalogSource{rc: rc, rev: s.Revision, from: "embedded snapshot"}, nil
+	}
+	if c, err = l.catalog(rc); err != nil {
+		return nil, nil, err
+	}
+	src := &catalogSource{rc: rc, from: "cache"}
+	if lc, ok := l.locked(rc.Name); ok {
+		src.rev, src.from = lc.Commit, LockfileName
+	}
+	return c, src, nil
+}
@@ -0,0 +1,281 @@
+package hook
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/viper"
+)
+
+// fakeGit answers the git commands used to read catalogs from a cache with
+// the given commits and head checked out. Revisions of remote are added to
+// the cache when they are fetched.
+type fakeGit struct {
+	commits   map[string]MemCatalog
+	head      string
+	remote    map[string]MemCatalog
+	fetchHead string
+}
+
+func (g *fakeGit) output(args []string) ([]byte, error) {
+	// git --git-dir <dir> <command> ...
+	args = args[3:]
+	switch args[0] {
+	case "rev-parse":
+		if args[1] == "FETCH_HEAD" {
+			return []byte(g.fetchHead + "\n"), nil
+		}
+		// rev-parse --verify -q <rev>^{commit}
+		rev := strings.TrimSuffix(args[3], "^{commit}")
+		if rev == "HEAD" {
+			rev = g.head
+		}
+		if _, ok := g.commits[rev]; !ok {
+			return nil, errors.New("not a commit")
+		}
+		return []byte(rev + "\n"), nil
+	case "fetch":
+		rev := args[3]
+		m, ok := g.remote[rev]
+		if !ok {
+			return nil, fmt.Errorf("couldn't find remote ref %s", rev)
+		}
+		g.fetchHead = "fetched-" + rev
+		g.commits[g.fetchHead] = m
+		return nil, nil
+	case "show":
+		s := strings.SplitN(args[1], ":", 2)
+		b, ok := g.commits[s[0]][s[1]]
+		if !ok {
+			return nil, fmt.Errorf("path %s does not exist", s[1])
+		}
+		return b, nil
+	case "ls-tree":
+		m := g.commits[args[3]]
+		p := ""
+		if len(args) > 5 {
+			p = args[5]
+		}
+		var infos []os.FileInfo
+		var dir string
+		if p == "" || strings.HasSuffix(p, "/") {
+			dir = strings.TrimSuffix(p, "/")
+			infos, _ = m.ReadDir(dir)
+		} else if fi, err := m.Stat(p); err == nil {
+			dir = path.Dir(p)
+			infos = []os.FileInfo{fi}
+		}
+		var out strings.Builder
+		for _, fi := range infos {
+			full := path.Join(dir, fi.Name())
+			if fi.IsDir() {
+				fmt.Fprintf(&out, "040000 tree 0123456789abcdef       -\t%s\x00", full)
+			} else {
+				fmt.Fprintf(&out, "100644 blob 0123456789abcdef %7d\t%s\x00", fi.Size(), full)
+			}
+		}
+		return []byte(out.String()), nil
+	}
+	return nil, fmt.Errorf("unexpected command %v", args)
+}
+
+// chdir changes the working directory for the rest of the test.
+func chdir(t *testing.T, dir string) func() {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	return func() { os.Chdir(wd) }
+}
+
+func TestRevCatalog(t *testing.T) {
+	d := testdirInit(t)
+	defer os.RemoveAll(d)
+
+	git := &fakeGit{
+		commits: map[string]MemCatalog{
+			"abc": {
+				"github/push.yaml": []byte("method: POST\n"),
+				"github/ping.yml":  []byte("method: GET\n"),
+				"README.md":        []byte("hi"),
+			},
+		},
+		head: "abc",
+	}
+	c := &commandSink{output: git.output}
+	c.install()
+	rc := &RemoteConfig{Name: "foo", URL: "http://example.com/foo"}
+
+	if _, err := rc.At("nope"); err == nil {
+		t.Error("expected error for commit missing from the cache")
+	}
+	cat, err := rc.At("abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, p, err := OpenHook(cat, "github/push")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hooks, err := New(f)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p != "github/push.yaml" || len(hooks) != 1 || hooks[0].Method != "POST" {
+		t.Errorf("OpenHook(github/push) = %s, %+v", p, hooks)
+	}
+	if _, _, err := OpenHook(cat, "github"); err == nil {
+		t.Error("expected error opening a directory")
+	}
+	if _, _, err := OpenHook(cat, "github/nope"); !os.IsNotExist(err) {
+		t.Errorf("OpenHook(github/nope): got %v, want not exist", err)
+	}
+
+	var got []string
+	if err := WalkCatalog(cat, "", func(p string) error {
+		got = append(got, p)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"github/ping.yml", "github/push.yaml"}, got); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestLockfile(t *testing.T) {
+	d := testdirInit(t)
+	defer os.RemoveAll(d)
+
+	sub := filepath.Join(d, "project", "sub")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	defer chdir(t, sub)()
+
+	if l, path, err := LoadLockfile(); err != nil || l != nil {
+		t.Fatalf("LoadLockfile without lockfile = (%v, %s, %v)", l, path, err)
+	}
+
+	git := &fakeGit{
+		commits: map[string]MemCatalog{
+			"v1": {"push.yaml": []byte("method: POST\n")},
+			"v2": {"push.yaml": []byte("method: PUT\n"), "pull.yaml": []byte("method: POST\n")},
+		},
+		head: "v1",
+	}
+	c := &commandSink{output: git.output}
+	c.install()
+	viper.Set("catalog.remote", []*RemoteConfig{
+		{Name: "foo", URL: "http://example.com/foo"},
+		{Name: "bar", URL: "http://example.com/bar"},
+	})
+	rcs, err := GetRemoteConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Lock the catalogs in a parent of the working directory.
+	l := &Lockfile{}
+	if err := l.Lock(rcs, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	lockPath := filepath.Join(d, "project", LockfileName)
+	if err := l.Write(lockPath); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(lockPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := lockfileHeader + `catalogs:
+  foo:
+    url: http://example.com/foo
+    commit: v1
+`
+	if diff := cmp.Diff(want, string(b)); diff != "" {
+		t.Error(diff)
+	}
+
+	l, path, err := LoadLockfile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != lockPath {
+		t.Errorf("LoadLockfile found %s, want %s", path, lockPath)
+	}
+	if problems, err := l.Check(rcs); err != nil || len(problems) != 0 {
+		t.Errorf("Check = (%v, %v), want no problems", problems, err)
+	}
+
+	// Hooks are read at the locked commit, whatever is checked out.
+	l.Catalogs["foo"].Commit = "v2"
+	if err := l.Write(lockPath); err != nil {
+		t.Fatal(err)
+	}
+	hooks, err := NewFromPath("foo@push")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hooks[0].Method != "PUT" {
+		t.Errorf("got method %s, want the locked PUT", hooks[0].Method)
+	}
+	listings, err := ListCatalogs("foo@")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var refs []string
+	for _, e := range listings[0].Hooks {
+		refs = append(refs, e.Ref)
+	}
+	if diff := cmp.Diff([]string{"foo@pull", "foo@push"}, refs); diff != "" {
+		t.Errorf("ListCatalogs didn't list the locked commit: %s", diff)
+	}
+	results, err := SearchCatalogs("pull")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Ref != "foo@pull" {
+		t.Errorf("SearchCatalogs didn't search the locked commit: %+v", results)
+	}
+
+	// Unlocked catalogs use the cache as is.
+	if err := rcs["bar"].Clone(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(rcs["bar"].Path(), "push.yaml"), []byte("method: GET\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if hooks, err = NewFromPath("bar@push"); err != nil || hooks[0].Method != "GET" {
+		t.Errorf("NewFromPath(bar@push) = (%v, %v)", hooks, err)
+	}
+
+	// Check reports everything that doesn't match.
+	l.Catalogs["bar"] = &LockedCatalog{URL: "http://example.com/moved", Commit: "v1"}
+	l.Catalogs["baz"] = &LockedCatalog{URL: "http://example.com/baz", Commit: "v1"}
+	problems, err := l.Check(rcs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantProblems := []string{
+		"catalog bar is locked to http://example.com/moved but configured as http://example.com/bar",
+		"catalog baz is locked but not configured",
+		"catalog foo is at v1, locked at v2",
+	}
+	if diff := cmp.Diff(wantProblems, problems); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,156 @@
+package hook
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ManifestFile is the optional file at the root of a catalog that describes
+// it.
+const ManifestFile = "catalog.yaml"
+
+// Manifest describes a catalog and the defaults of the hooks in it.
+type Manifest struct {
+	Name        string   `yaml:"name,omitempty" json:"name,omitempty"`
+	Description string   `yaml:"description,omitempty" json:"description,omitempty"`
+	Maintainers []string `yaml:"maintainers,omitempty" json:"maintainers,omitempty"`
+	// Providers are the providers whose hooks the catalog has.
+	Providers []string `yaml:"providers,omitempty" json:"providers,omitempty"`
+	// MinHookVersion is the oldest version of hook that can use the catalog.
+	MinHookVersion string `yaml:"minHookVersion,omitempty" json:"minHookVersion,omitempty"`
+	// Vars are default template variables of every hook in the catalog.
+	Vars map[string]string `yaml:"vars,omitempty" json:"vars,omitempty"`
+	// Signing maps provider directories, e.g. github or stripe/connect, to
+	// how the hooks below them are signed.
+	Signing map[string]*Signing `yaml:"signing,omitempty" json:"signing,omitempty"`
+}
+
+// ReadManifest reads the manifest of the catalog. It returns nil if the
+// catalog doesn't have one.
+func ReadManifest(c Catalog) (*Manifest, error) {
+	f, err := c.Open(ManifestFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	b, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	m := &Manifest{}
+	if err := yaml.UnmarshalStrict(b, m); err != nil {
+		d := &document{Line: 1, Data: b}
+		return nil, fmt.Errorf("%s: %v", ManifestFile, d.errorf(err))
+	}
+	for dir, s := range m.Signing {
+		if s == nil {
+			return nil, fmt.Errorf("%s: signing %s is empty", ManifestFile, dir)
+		}
+		if err := s.Validate(); err != nil {
+			return nil, fmt.Errorf("%s: signing %s: %v", ManifestFile, dir, err)
+		}
+	}
+	return m, nil
+}
+
+// Manifest returns the manifest of the remote catalog, or nil if it doesn't
+// have one.
+func (rc *RemoteConfig) Manifest() (*Manifest, error) {
+	return ReadManifest(rc)
+}
+
+// CheckVersion returns an error if this version of hook is older than the
+// catalog's minimum hook version.
+func (m *Manifest) CheckVersion() error {
+	if m.MinHookVersion == "" {
+		return nil
+	}
+	min, ok := parseVersion(m.MinHookVersion)
+	if !ok {
+		return fmt.Errorf("%s: invalid minHookVersion %q", ManifestFile, m.MinHookVersion)
+	}
+	v, ok := parseVersion(Version)
+	if !ok {
+		// Development builds don't have a comparable version.
+		return nil
+	}
+	for i := range v {
+		if v[i] != min[i] {
+			if v[i] < min[i] {
+				return fmt.Errorf("catalog requires hook %s or newer, this is %s", m.MinHookVersion, Version)
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// parseVersion parses versions of the form [v]major[.minor[.patch]] with an
+// optional pre-release or build suffix, which is ignored.
+func parseVersion(s string) ([3]int, bool) {
+	var v [3]int
+	s = strings.TrimPrefix(s, "v")
+	if i := strings.IndexAny(s, "-+"); i >= 0 {
+		s = s[:i]
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return v, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return v, false
+		}
+		v[i] = n
+	}
+	return v, true
+}
+
+// signing returns the signing of the deepest provider directory containing
+// the file at p.
+func (m *Manifest) signing(p string) *Signing {
+	var best string
+	var s *Signing
+	for dir, ds := range m.Signing {
+		dir = strings.Trim(path.Clean("/"+dir), "/")
+		if (dir == "" || strings.HasPrefix(p, dir+"/")) && (s == nil || len(dir) > len(best)) {
+			best, s = dir, ds
+		}
+	}
+	return s
+}
+
+// Apply sets the catalog defaults on the hooks of the file at p. Variables
+// and signing of the hooks themselves take precedence. Signing set by Apply
+// is Inherited.
+func (m *Manifest) Apply(p string, hooks []*Hook) {
+	s := m.signing(p)
+	for _, h := range hooks {
+		if len(m.Vars) > 0 {
+			vars := make(map[string]string, len(m.Vars)+len(h.Vars))
+			for k, v := range m.Vars {
+				vars[k] = v
+			}
+			for k, v := range h.Vars {
+				vars[k] = v
+			}
+			h.Vars = vars
+		}
+		if h.Signing == nil && s != nil {
+			c := *s
+			c.Inherited = true
+			h.Signing = &c
+		}
+	}
+}
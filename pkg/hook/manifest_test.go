@@ -0,0 +1,244 @@
+package hook
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/viper"
+)
+
+const testManifest = `name: test
+description: Hooks for tests
+maintainers:
+- someone@example.com
+providers:
+- github
+- stripe
+minHookVersion: 0.0.1
+vars:
+  repo: hook
+  owner: eddiezane
+signing:
+  github:
+    header: X-Hub-Signature-256
+    prefix: sha256=
+    secret: '{{ env "GITHUB_WEBHOOK_SECRET" }}'
+  github/apps:
+    header: X-Hub-Signature
+    algorithm: sha1
+    secret: '{{ .app_secret }}'
+`
+
+func TestReadManifest(t *testing.T) {
+	m, err := ReadManifest(MemCatalog{"push.yaml": nil})
+	if err != nil || m != nil {
+		t.Errorf("ReadManifest without manifest = (%v, %v), want (nil, nil)", m, err)
+	}
+
+	m, err = ReadManifest(MemCatalog{ManifestFile: []byte(testManifest)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Name != "test" || len(m.Providers) != 2 || len(m.Signing) != 2 {
+		t.Errorf("got %+v", m)
+	}
+
+	for _, in := range []string{
+		"nmae: typo\n",
+		"signing:\n  github:\n    header: X\n    algorithm: md5\n",
+		"signing:\n  github:\n",
+	} {
+		if _, err := ReadManifest(MemCatalog{ManifestFile: []byte(in)}); err == nil {
+			t.Errorf("ReadManifest(%q): expected error", in)
+		}
+	}
+}
+
+func TestManifestApply(t *testing.T) {
+	m, err := ReadManifest(MemCatalog{ManifestFile: []byte(testManifest)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	own := &Signing{Header: "X-Own", Secret: "{{ .own }}"}
+	inherited := func(dir string) *Signing {
+		s := *m.Signing[dir]
+		s.Inherited = true
+		return &s
+	}
+	tests := []struct {
+		path    string
+		hook    *Hook
+		vars    map[string]string
+		signing *Signing
+	}{
+		{
+			path:    "github/push.yaml",
+			hook:    &Hook{Vars: map[string]string{"repo": "other"}},
+			vars:    map[string]string{"repo": "other", "owner": "eddiezane"},
+			signing: inherited("github"),
+		},
+		{
+			path:    "github/apps/install.yaml",
+			hook:    &Hook{},
+			vars:    map[string]string{"repo": "hook", "owner": "eddiezane"},
+			signing: inherited("github/apps"),
+		},
+		{
+			path:    "github/ping.yaml",
+			hook:    &Hook{Signing: own},
+			vars:    map[string]string{"repo": "hook", "owner": "eddiezane"},
+			signing: own,
+		},
+		{
+			path: "stripe/charge.yaml",
+			hook: &Hook{},
+			vars: map[string]string{"repo": "hook", "owner": "eddiezane"},
+		},
+		{
+			path: "githubber/push.yaml",
+			hook: &Hook{},
+			vars: map[string]string{"repo": "hook", "owner": "eddiezane"},
+		},
+	}
+	for _, tc := range tests {
+		m.Apply(tc.path, []*Hook{tc.hook})
+		if diff := cmp.Diff(tc.vars, tc.hook.Vars); diff != "" {
+			t.Errorf("%s: vars: %s", tc.path, diff)
+		}
+		if diff := cmp.Diff(tc.signing, tc.hook.Signing); diff != "" {
+			t.Errorf("%s: signing: %s", tc.path, diff)
+		}
+	}
+}
+
+func TestManifestCheckVersion(t *testing.T) {
+	defer func(v string) { Version = v }(Version)
+
+	tests := []struct {
+		version, min string
+		ok           bool
+	}{
+		{version: "0.0.1", min: "", ok: true},
+		{version: "0.0.1", min: "0.0.1", ok: true},
+		{version: "v1.2.0", min: "1.1", ok: true},
+		{version: "1.10.0", min: "v1.9.3", ok: true},
+		{version: "1.2.0-rc.1", min: "1.2", ok: true},
+		{version: "0.0.1", min: "0.1.0", ok: false},
+		{version: "1.9.9", min: "1.10", ok: false},
+		{version: "dev", min: "1.0.0", ok: true},
+		{version: "1.0.0", min: "latest", ok: false},
+	}
+	for _, tc := range tests {
+		Version = tc.version
+		err := (&Manifest{MinHookVersion: tc.min}).CheckVersion()
+		if (err == nil) != tc.ok {
+			t.Errorf("version %s, min %s: got %v, want ok %t", tc.version, tc.min, err, tc.ok)
+		}
+	}
+}
+
+func TestCatalogManifest(t *testing.T) {
+	d := testdirInit(t)
+	defer os.RemoveAll(d)
+	defer func(v string) { Version = v }(Version)
+
+	c := &commandSink{}
+	c.install()
+	viper.Set("catalog.remote", []*RemoteConfig{{Name: "foo", URL: "http://example.com/foo"}})
+	rc, err := GetRemoteConfig("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rc.Clone(); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		ManifestFile: testManifest,
+		"github/push.yaml": `apiVersion: hook/v1
+kind: Hook
+method: POST
+headers:
+  X-GitHub-Event:
+  - push
+body: '{"repository": "{{ .owner }}/{{ .repo }}"}'
+`,
+	}
+	for p, data := range files {
+		path := filepath.Join(rc.Path(), filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Hooks fired from the catalog use its defaults.
+	os.Setenv("GITHUB_WEBHOOK_SECRET", "secret")
+	defer os.Unsetenv("GITHUB_WEBHOOK_SECRET")
+	hooks, err := NewFromPath("foo@github/push")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := hooks[0].toRequest("http://localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"repository": "eddiezane/hook"}` {
+		t.Errorf("body = %s", b)
+	}
+	if !strings.HasPrefix(r.Header.Get("X-Hub-Signature-256"), "sha256=") {
+		t.Errorf("request was not signed: %v", r.Header)
+	}
+
+	// The manifest is listed with the catalog, but not as a hook.
+	listings, err := ListCatalogs("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listings) != 1 || listings[0].Manifest == nil || listings[0].Manifest.Name != "test" {
+		t.Fatalf("got listings %+v", listings)
+	}
+	if len(listings[0].Hooks) != 1 || listings[0].Hooks[0].Ref != "foo@github/push" {
+		t.Errorf("got hooks %+v", listings[0].Hooks)
+	}
+	var out bytes.Buffer
+	if err := PrintCatalogListings(&out, listings); err != nil {
+		t.Fatal(err)
+	}
+	want := `foo http://example.com/foo
+  Hooks for tests
+  providers: github, stripe
+  maintainers: someone@example.com
+
+REF              HOOKS  EVENTS  NAME
+foo@github/push  1      push    ` + "\n"
+	if diff := cmp.Diff(want, out.String()); diff != "" {
+		t.Error(diff)
+	}
+
+	// foo@ shows the manifest.
+	out.Reset()
+	if err := ShowHook(&out, "foo@"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out.String(), "---\n# foo http://example.com/foo (cache)\nname: test\n") {
+		t.Errorf("ShowHook(foo@) = %s", out.String())
+	}
+
+	// Catalogs can require newer versions of hook.
+	Version = "0.0.0"
+	if _, err := NewFromPath("foo@github/push"); err == nil {
+		t.Error("expected error for catalog requiring a newer hook")
+	}
+}
@@ -0,0 +1,493 @@
+package hook
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Media types of catalog artifacts.
+const (
+	ociManifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+	ociConfigMediaType   = "application/vnd.hook.catalog.config.v1+json"
+	ociLayerMediaType    = "application/vnd.hook.catalog.layer.v1.tar+gzip"
+)
+
+// OCIScheme is the scheme of catalogs distributed as OCI artifacts, such as
+// oci://registry.example.com/catalogs/github:v1.
+const OCIScheme = "oci://"
+
+// ociReference is a parsed oci://<registry>/<repository>[:<tag>|@<digest>]
+// URL.
+type ociReference struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+func parseOCIReference(rawurl string) (*ociReference, error) {
+	if !strings.HasPrefix(rawurl, OCIScheme) {
+		return nil, fmt.Errorf("%s: not an %s URL", rawurl, OCIScheme)
+	}
+	s := strings.SplitN(strings.TrimPrefix(rawurl, OCIScheme), "/", 2)
+	if len(s) != 2 || s[0] == "" || s[1] == "" {
+		return nil, fmt.Errorf("%s: expected %s<registry>/<repository>[:<tag>|@<digest>]", rawurl, OCIScheme)
+	}
+	ref := &ociReference{Registry: s[0], Repository: s[1], Tag: "latest"}
+	if i := strings.Index(ref.Repository, "@"); i >= 0 {
+		ref.Repository, ref.Tag, ref.Digest = ref.Repository[:i], "", ref.Repository[i+1:]
+		if !strings.HasPrefix(ref.Digest, "sha256:") {
+			return nil, fmt.Errorf("%s: unsupported digest %s", rawurl, ref.Digest)
+		}
+	} else if i := strings.LastIndex(ref.Repository, ":"); i > strings.LastIndex(ref.Repository, "/") {
+		ref.Repository, ref.Tag = ref.Repository[:i], ref.Repository[i+1:]
+	}
+	if ref.Repository == "" || ref.Tag == "" && ref.Digest == "" {
+		return nil, fmt.Errorf("%s: expected %s<registry>/<repository>[:<tag>|@<digest>]", rawurl, OCIScheme)
+	}
+	return ref, nil
+}
+
+// reference returns the digest of the reference, or its tag.
+func (r *ociReference) reference() string {
+	if r.Digest != "" {
+		return r.Digest
+	}
+	return r.Tag
+}
+
+// ociManifest is an OCI image manifest.
+type ociManifest struct {
+	SchemaVersion int             `json:"schemaVersion"`
+	MediaType     string          `json:"mediaType"`
+	Config        ociDescriptor   `json:"config"`
+	Layers        []ociDescriptor `json:"layers"`
+}
+
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// catalogLayer returns the layer with the files of the catalog.
+func (m *ociManifest) catalogLayer() (*ociDescriptor, error) {
+	for i, l := range m.Layers {
+		if l.MediaType == ociLayerMediaType || strings.HasSuffix(l.MediaType, "tar+gzip") {
+			return &m.Layers[i], nil
+		}
+	}
+	return nil, fmt.Errorf("artifact has no %s layer", ociLayerMediaType)
+}
+
+// registryError is an error response of a registry.
+type registryError struct {
+	StatusCode int
+	Status     string
+	Message    string
+}
+
+func (e *registryError) Error() string {
+	if e.Message == "" {
+		return e.Status
+	}
+	return e.Status + ": " + e.Message
+}
+
+// checkResponse returns a *registryError if resp doesn't have one of the
+// expected status codes.
+func checkResponse(resp *http.Response, expected ...int) error {
+	for _, code := range expected {
+		if resp.StatusCode == code {
+			return nil
+		}
+	}
+	e := &registryError{StatusCode: resp.StatusCode, Status: resp.Status}
+	var body struct {
+		Errors []struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if b, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<16)); err == nil && json.Unmarshal(b, &body) == nil {
+		var msgs []string
+		for _, err := range body.Errors {
+			msgs = append(msgs, strings.TrimSpace(err.Code+" "+err.Message))
+		}
+		e.Message = strings.Join(msgs, "; ")
+	}
+	return e
+}
+
+// registryClient talks to the registry of a reference with the OCI
+// distribution API. Credentials are read from HOOK_OCI_USERNAME and
+// HOOK_OCI_PASSWORD.
+type registryClient struct {
+	ref      *ociReference
+	client   *http.Client
+	username string
+	password string
+	token    string
+}
+
+// registryHTTPClient is shared by registry clients.
+var registryHTTPClient = &http.Client{Transport: http.DefaultTransport}
+
+func newRegistryClient(ref *ociReference) *registryClient {
+	return &registryClient{
+		ref:      ref,
+		client:   registryHTTPClient,
+		username: os.Getenv("HOOK_OCI_USERNAME"),
+		password: os.Getenv("HOOK_OCI_PASSWORD"),
+	}
+}
+
+// url returns the URL of an API path of the repository. Registries on the
+// local machine are accessed over plain HTTP.
+func (c *registryClient) url(format string, args ...interface{}) string {
+	scheme := "https"
+	host := c.ref.Registry
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if host == "localhost" || net.ParseIP(host).IsLoopback() {
+		scheme = "http"
+	}
+	return fmt.Sprintf("%s://%s/v2/%s/%s", scheme, c.ref.Registry, c.ref.Repository, fmt.Sprintf(format, args...))
+}
+
+// do sends a request, authorizing with the registry if it asks to.
+func (c *registryClient) do(method, u string, header http.Header, body []byte) (*http.Response, error) {
+	for authorized := false; ; authorized = true {
+		req, err := http.NewRequest(method, u, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		} else if c.username != "" {
+			req.SetBasicAuth(c.username, c.password)
+		}
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusUnauthorized || authorized {
+			return resp, nil
+		}
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		if err := c.authorize(challenge); err != nil {
+			return nil, err
+		}
+	}
+}
+
+var challengeParams = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// authorize gets a token for a bearer challenge of the registry.
+func (c *registryClient) authorize(challenge string) error {
+	unauthorized := &registryError{StatusCode: http.StatusUnauthorized, Status: "401 Unauthorized"}
+	if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		if c.username == "" {
+			unauthorized.Message = "set HOOK_OCI_USERNAME and HOOK_OCI_PASSWORD"
+		}
+		return unauthorized
+	}
+	params := map[string]string{}
+	for _, m := range challengeParams.FindAllStringSubmatch(challenge, -1) {
+		params[strings.ToLower(m[1])] = m[2]
+	}
+	u, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return fmt.Errorf("invalid registry challenge %q", challenge)
+	}
+	q := u.Query()
+	for _, k := range []string{"service", "scope"} {
+		if params[k] != "" {
+			q.Set(k, params[k])
+		}
+	}
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, http.StatusOK); err != nil {
+		return err
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return fmt.Errorf("decoding registry token: %v", err)
+	}
+	c.token = token.Token
+	if c.token == "" {
+		c.token = token.AccessToken
+	}
+	if c.token == "" {
+		return unauthorized
+	}
+	return nil
+}
+
+// manifest returns a manifest and its digest.
+func (c *registryClient) manifest(reference string) (*ociManifest, string, error) {
+	header := http.Header{"Accept": {ociManifestMediaType}}
+	resp, err := c.do(http.MethodGet, c.url("manifests/%s", reference), header, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, http.StatusOK); err != nil {
+		return nil, "", err
+	}
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	digest := sha256Digest(b)
+	if strings.HasPrefix(reference, "sha256:") && reference != digest {
+		return nil, "", fmt.Errorf("manifest %s has digest %s", reference, digest)
+	}
+	m := new(ociManifest)
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, "", fmt.Errorf("decoding manifest %s: %v", reference, err)
+	}
+	return m, digest, nil
+}
+
+// manifestDigest returns the digest of a manifest, without downloading it if
+// the registry reports it.
+func (c *registryClient) manifestDigest(reference string) (string, error) {
+	header := http.Header{"Accept": {ociManifestMediaType}}
+	resp, err := c.do(http.MethodHead, c.url("manifests/%s", reference), header, nil)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if err := checkResponse(resp, http.StatusOK); err != nil {
+		return "", err
+	}
+	if d := resp.Header.Get("Docker-Content-Digest"); strings.HasPrefix(d, "sha256:") {
+		return d, nil
+	}
+	_, digest, err := c.manifest(reference)
+	return digest, err
+}
+
+// blob returns the contents of a blob. The caller verifies its digest.
+func (c *registryClient) blob(digest string) (io.ReadCloser, error) {
+	resp, err := c.do(http.MethodGet, c.url("blobs/%s", digest), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkResponse(resp, http.StatusOK); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// pushBlob uploads a blob, unless the registry already has it.
+func (c *registryClient) pushBlob(b []byte) (*ociDescriptor, error) {
+	desc := &ociDescriptor{Digest: sha256Digest(b), Size: int64(len(b))}
+	resp, err := c.do(http.MethodHead, c.url("blobs/%s", desc.Digest), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return desc, nil
+	}
+
+	resp, err = c.do(http.MethodPost, c.url("blobs/uploads/"), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if err := checkResponse(resp, http.StatusAccepted); err != nil {
+		return nil, err
+	}
+	loc, err := resp.Request.URL.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid upload location: %v", err)
+	}
+	q := loc.Query()
+	q.Set("digest", desc.Digest)
+	loc.RawQuery = q.Encode()
+
+	header := http.Header{"Content-Type": {"application/octet-stream"}}
+	resp, err = c.do(http.MethodPut, loc.String(), header, b)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return desc, checkResponse(resp, http.StatusCreated)
+}
+
+// pushManifest uploads a manifest and tags it, returning its digest.
+func (c *registryClient) pushManifest(tag string, m *ociManifest) (string, error) {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return "", err
+	}
+	header := http.Header{"Content-Type": {ociManifestMediaType}}
+	resp, err := c.do(http.MethodPut, c.url("manifests/%s", tag), header, b)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, http.StatusCreated); err != nil {
+		return "", err
+	}
+	return sha256Digest(b), nil
+}
+
+func sha256Digest(b []byte) string {
+	sum := sha256.Sum256(b)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// PushCatalog packages the catalog in dir and pushes it as an OCI artifact to
+// an oci://<registry>/<repository>:<tag> URL. It returns the digest of the
+// artifact, which can be pinned with oci://<registry>/<repository>@<digest>.
+// Files and directories starting with a dot are left out, and the same files
+// always result in the same digest.
+func PushCatalog(dir, rawurl string) (string, error) {
+	ref, err := parseOCIReference(rawurl)
+	if err != nil {
+		return "", err
+	}
+	if ref.Digest != "" {
+		return "", fmt.Errorf("%s: can't push to a digest, use a tag", rawurl)
+	}
+
+	config := []byte("{}")
+	m, err := ReadManifest(DirCatalog(dir))
+	if err != nil {
+		return "", err
+	}
+	if m != nil {
+		if config, err = json.Marshal(m); err != nil {
+			return "", err
+		}
+	}
+	layer, err := packCatalog(dir)
+	if err != nil {
+		return "", err
+	}
+
+	c := newRegistryClient(ref)
+	configDesc, err := c.pushBlob(config)
+	if err != nil {
+		return "", fmt.Errorf("pushing %s: %v", rawurl, err)
+	}
+	configDesc.MediaType = ociConfigMediaType
+	layerDesc, err := c.pushBlob(layer)
+	if err != nil {
+		return "", fmt.Errorf("pushing %s: %v", rawurl, err)
+	}
+	layerDesc.MediaType = ociLayerMediaType
+	layerDesc.Annotations = map[string]string{"org.opencontainers.image.title": "catalog.tar.gz"}
+
+	digest, err := c.pushManifest(ref.Tag, &ociManifest{
+		SchemaVersion: 2,
+		MediaType:     ociManifestMediaType,
+		Config:        *configDesc,
+		Layers:        []ociDescriptor{*layerDesc},
+	})
+	if err != nil {
+		return "", fmt.Errorf("pushing %s: %v", rawurl, err)
+	}
+	return digest, nil
+}
+
+// packCatalog returns a reproducible .tar.gz archive of the files in dir.
+func packCatalog(dir string) ([]byte, error) {
+	var paths []string
+	err := filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && strings.HasPrefix(fi.Name(), ".") {
+			if fi.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if fi.Mode().IsRegular() {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, path := range paths {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return nil, err
+		}
+		hdr := &tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     filepath.ToSlash(rel),
+			Mode:     0644,
+			Size:     int64(len(b)),
+			ModTime:  time.Unix(0, 0),
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(b); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
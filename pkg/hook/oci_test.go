@@ -0,0 +1,215 @@
+package hook
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/viper"
+)
+
+// testRegistry is a minimal OCI distribution API stand-in that requires a
+// bearer token from its /token endpoint.
+type testRegistry struct {
+	mu        sync.Mutex
+	blobs     map[string][]byte
+	manifests map[string][]byte
+	uploads   int
+}
+
+func newTestRegistry(t *testing.T) (*testRegistry, *httptest.Server) {
+	t.Helper()
+	reg := &testRegistry{blobs: map[string][]byte{}, manifests: map[string][]byte{}}
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			fmt.Fprint(w, `{"token": "t0ken"}`)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer t0ken" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test"`, srv.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		reg.serveHTTP(w, r)
+	}))
+	return reg, srv
+}
+
+func (reg *testRegistry) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/v2/")
+	switch {
+	case strings.HasSuffix(path, "/blobs/uploads/") && r.Method == http.MethodPost:
+		w.Header().Set("Location", "/v2/"+path+"session")
+		w.WriteHeader(http.StatusAccepted)
+	case strings.HasSuffix(path, "/blobs/uploads/session") && r.Method == http.MethodPut:
+		b, _ := ioutil.ReadAll(r.Body)
+		if sha256Digest(b) != r.URL.Query().Get("digest") {
+			http.Error(w, `{"errors": [{"code": "DIGEST_INVALID"}]}`, http.StatusBadRequest)
+			return
+		}
+		reg.uploads++
+		reg.blobs[sha256Digest(b)] = b
+		w.WriteHeader(http.StatusCreated)
+	case strings.Contains(path, "/blobs/"):
+		b, ok := reg.blobs[path[strings.LastIndex(path, "/")+1:]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Method == http.MethodGet {
+			w.Write(b)
+		}
+	case strings.Contains(path, "/manifests/"):
+		i := strings.Index(path, "/manifests/")
+		key := path[:i] + "@" + path[i+len("/manifests/"):]
+		if r.Method == http.MethodPut {
+			b, _ := ioutil.ReadAll(r.Body)
+			reg.manifests[key] = b
+			reg.manifests[path[:i]+"@"+sha256Digest(b)] = b
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		b, ok := reg.manifests[key]
+		if !ok {
+			http.Error(w, `{"errors": [{"code": "MANIFEST_UNKNOWN", "message": "manifest unknown"}]}`, http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", ociManifestMediaType)
+		w.Header().Set("Docker-Content-Digest", sha256Digest(b))
+		if r.Method == http.MethodGet {
+			w.Write(b)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestParseOCIReference(t *testing.T) {
+	for u, want := range map[string]*ociReference{
+		"oci://ghcr.io/acme/catalog":                   {Registry: "ghcr.io", Repository: "acme/catalog", Tag: "latest"},
+		"oci://localhost:5000/catalog:v1":              {Registry: "localhost:5000", Repository: "catalog", Tag: "v1"},
+		"oci://ghcr.io/acme/catalog@sha256:0123abcdef": {Registry: "ghcr.io", Repository: "acme/catalog", Digest: "sha256:0123abcdef"},
+		"oci://ghcr.io":                                nil,
+		"oci://ghcr.io/acme/catalog:":                  nil,
+		"oci://ghcr.io/acme/catalog@md5:0123":          nil,
+		"https://ghcr.io/acme/catalog":                 nil,
+	} {
+		got, err := parseOCIReference(u)
+		if want == nil {
+			if err == nil {
+				t.Errorf("parseOCIReference(%s) = %+v, want an error", u, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseOCIReference(%s): %v", u, err)
+			continue
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("parseOCIReference(%s) mismatch (-want +got):\n%s", u, diff)
+		}
+	}
+}
+
+func TestOCIFetcher(t *testing.T) {
+	d := testdirInit(t)
+	defer os.RemoveAll(d)
+	reg, srv := newTestRegistry(t)
+	defer srv.Close()
+
+	src := filepath.Join(d, "src")
+	write := func(name, data string) {
+		t.Helper()
+		p := filepath.Join(src, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("catalog.yaml", "name: team\n")
+	write("github/push.yaml", "method: POST\n")
+	write(".git/HEAD", "ref: refs/heads/master\n")
+
+	url := "oci://" + strings.TrimPrefix(srv.URL, "http://") + "/team/catalog"
+	v1, err := PushCatalog(src, url+":v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, err := PushCatalog(src, url+":v1"); err != nil || again != v1 {
+		t.Errorf("pushing again = (%s, %v), want %s", again, err, v1)
+	}
+	if reg.uploads != 2 {
+		t.Errorf("got %d blob uploads, want 2", reg.uploads)
+	}
+
+	viper.Set("catalog.remote", []*RemoteConfig{{Name: "team", URL: url + ":v1"}})
+	hooks, err := NewFromPath("team@github/push")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hooks[0].Method != "POST" {
+		t.Errorf("got method %s, want POST", hooks[0].Method)
+	}
+	rc, err := GetRemoteConfig("team")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if commit, err := rc.Commit(); err != nil || commit != v1 {
+		t.Errorf("Commit() = (%s, %v), want %s", commit, err, v1)
+	}
+	if _, err := os.Stat(filepath.Join(rc.Path(), ".git")); !os.IsNotExist(err) {
+		t.Errorf("dot directory was pushed: %v", err)
+	}
+
+	// Moving the tag is picked up by an update, and the old digest stays
+	// available as a revision.
+	write("github/push.yaml", "method: PUT\n")
+	v2, err := PushCatalog(src, url+":v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rc.Update(); err != nil {
+		t.Fatal(err)
+	}
+	if hooks, err = NewFromPath("team@github/push"); err != nil || hooks[0].Method != "PUT" {
+		t.Errorf("NewFromPath after update = (%v, %v)", hooks, err)
+	}
+	if commit, err := rc.Commit(); err != nil || commit != v2 {
+		t.Errorf("Commit() = (%s, %v), want %s", commit, err, v2)
+	}
+	if hooks, err = NewFromPath("team@github/push#" + v1); err != nil || hooks[0].Method != "POST" {
+		t.Errorf("NewFromPath at %s = (%v, %v)", v1, hooks, err)
+	}
+
+	if _, err := NewFromPath("team@github/push#v2"); !errors.Is(err, ErrRefNotFound) {
+		t.Errorf("got %v, want ErrRefNotFound", err)
+	}
+	pinned := &RemoteConfig{Name: "pinned", URL: url + "@" + v1}
+	if err := pinned.Clone(); err != nil {
+		t.Fatal(err)
+	}
+	if commit, err := pinned.Commit(); err != nil || commit != v1 {
+		t.Errorf("Commit() = (%s, %v), want %s", commit, err, v1)
+	}
+	bad := &RemoteConfig{Name: "bad", URL: url + ":v1", Checksum: v1}
+	if err := bad.Clone(); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("got %v, want a checksum mismatch", err)
+	}
+	if _, err := PushCatalog(src, url+"@"+v1); err == nil {
+		t.Error("expected an error pushing to a digest")
+	}
+}
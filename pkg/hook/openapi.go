@@ -0,0 +1,401 @@
+package hook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// maxSchemaDepth limits how deeply schemas are expanded when synthesizing
+// example bodies, which guards against recursive schemas.
+const maxSchemaDepth = 10
+
+// openAPIMethods are the operations of an OpenAPI path item, in the order
+// they are generated.
+var openAPIMethods = []string{"post", "put", "patch", "get", "delete", "head", "options", "trace"}
+
+// GeneratedHooks is a hook file generated from another format.
+type GeneratedHooks struct {
+	// Path is the relative path the hooks should be written to.
+	Path  string
+	Hooks []*Hook
+}
+
+// openAPI is a parsed OpenAPI document. Values are kept generic so that
+// $refs can be resolved anywhere in the document.
+type openAPI struct {
+	root map[string]interface{}
+}
+
+// NewFromOpenAPI generates hooks from the webhooks section of an OpenAPI 3.1
+// document (in YAML or JSON). One file is generated per webhook operation,
+// with one hook per example. If an operation has no examples, a body is
+// synthesized from its schema.
+func NewFromOpenAPI(r io.Reader) ([]*GeneratedHooks, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var raw interface{}
+	if err := yaml.Unmarshal(b, &raw); err != nil {
+		return nil, err
+	}
+	root, ok := normalizeYAML(raw).(map[string]interface{})
+	if !ok {
+		return nil, errors.New("invalid OpenAPI document")
+	}
+	doc := &openAPI{root: root}
+
+	webhooks, ok := doc.resolve(root["webhooks"], 0).(map[string]interface{})
+	if !ok || len(webhooks) == 0 {
+		return nil, errors.New("OpenAPI document has no webhooks")
+	}
+
+	var res []*GeneratedHooks
+	seen := make(map[string]int)
+	for _, name := range sortedKeys(webhooks) {
+		item, ok := doc.resolve(webhooks[name], 0).(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("webhook %s: invalid path item", name)
+		}
+
+		var methods []string
+		for _, m := range openAPIMethods {
+			if _, ok := item[m]; ok {
+				methods = append(methods, m)
+			}
+		}
+		for _, m := range methods {
+			op, ok := doc.resolve(item[m], 0).(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("webhook %s: invalid %s operation", name, m)
+			}
+			hooks, err := doc.operationHooks(name, m, item, op)
+			if err != nil {
+				return nil, fmt.Errorf("webhook %s: %v", name, err)
+			}
+			path := fileName(name)
+			if path == "" {
+				path = "webhook"
+			}
+			if len(methods) > 1 {
+				path += "-" + m
+			}
+			// Names which only differ in unsafe characters share a file name.
+			seen[path]++
+			if n := seen[path]; n > 1 {
+				path = fmt.Sprintf("%s-%d", path, n)
+			}
+			res = append(res, &GeneratedHooks{Path: path + ".yaml", Hooks: hooks})
+		}
+	}
+	return res, nil
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// fileName converts a name into something safe to use as a file name.
+func fileName(name string) string {
+	return strings.Trim(unsafeFileChars.ReplaceAllString(name, "-"), "-")
+}
+
+// normalizeYAML converts the map[interface{}]interface{} values produced by
+// the yaml package into map[string]interface{} so they can be encoded as
+// JSON.
+func normalizeYAML(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			m[fmt.Sprint(k)] = normalizeYAML(val)
+		}
+		return m
+	case []interface{}:
+		for i := range v {
+			v[i] = normalizeYAML(v[i])
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// resolve follows local $refs (e.g. #/components/schemas/Push).
+func (d *openAPI) resolve(v interface{}, depth int) interface{} {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return v
+	}
+	ref, ok := m["$ref"].(string)
+	if !ok || depth > maxSchemaDepth || !strings.HasPrefix(ref, "#/") {
+		return v
+	}
+
+	var cur interface{} = d.root
+	for _, p := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		p = strings.NewReplacer("~1", "/", "~0", "~").Replace(p)
+		cm, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		cur = cm[p]
+	}
+	return d.resolve(cur, depth+1)
+}
+
+func (d *openAPI) resolveMap(v interface{}) map[string]interface{} {
+	m, _ := d.resolve(v, 0).(map[string]interface{})
+	return m
+}
+
+// operationHooks generates one hook per example of the operation.
+func (d *openAPI) operationHooks(name, method string, item, op map[string]interface{}) ([]*Hook, error) {
+	base := &Hook{
+		Name:   name,
+		Method: strings.ToUpper(method),
+	}
+	if s, ok := op["summary"].(string); ok && s != "" {
+		base.Name = s
+	}
+	if s, ok := op["description"].(string); ok {
+		base.Description = strings.TrimSpace(s)
+	}
+
+	// Path item parameters apply to every operation.
+	var params []interface{}
+	if p, ok := item["parameters"].([]interface{}); ok {
+		params = append(params, p...)
+	}
+	if p, ok := op["parameters"].([]interface{}); ok {
+		params = append(params, p...)
+	}
+	for _, p := range params {
+		param := d.resolveMap(p)
+		if param == nil {
+			continue
+		}
+		pname, _ := param["name"].(string)
+		value := d.parameterValue(param)
+		switch param["in"] {
+		case "header":
+			if base.Headers == nil {
+				base.Headers = make(http.Header)
+			}
+			base.Headers.Set(pname, value)
+		case "query":
+			if base.Params == nil {
+				base.Params = make(url.Values)
+			}
+			base.Params.Set(pname, value)
+		}
+	}
+
+	body := d.resolveMap(op["requestBody"])
+	content := d.resolveMap(body["content"])
+	if len(content) == 0 {
+		return []*Hook{base}, nil
+	}
+
+	mediaType := sortedKeys(content)[0]
+	for _, t := range sortedKeys(content) {
+		if strings.Contains(t, "json") {
+			mediaType = t
+			break
+		}
+	}
+	media := d.resolveMap(content[mediaType])
+	if base.Headers == nil {
+		base.Headers = make(http.Header)
+	}
+	base.Headers.Set("Content-Type", mediaType)
+
+	// Collect the example bodies, falling back to a synthesized body.
+	type example struct {
+		name  string
+		value interface{}
+	}
+	var examples []example
+	if v, ok := media["example"]; ok {
+		examples = append(examples, example{value: v})
+	}
+	exs := d.resolveMap(media["examples"])
+	for _, k := range sortedKeys(exs) {
+		ex := d.resolveMap(exs[k])
+		if v, ok := ex["value"]; ok {
+			examples = append(examples, example{name: k, value: v})
+		}
+	}
+	if len(examples) == 0 {
+		examples = append(examples, example{value: d.synthesize(media["schema"], 0)})
+	}
+
+	var hooks []*Hook
+	for _, ex := range examples {
+		h := *base
+		h.Headers = base.Headers.Clone()
+		if ex.name != "" && len(examples) > 1 {
+			h.Name = fmt.Sprintf("%s (%s)", base.Name, ex.name)
+		}
+		b, err := encodeBody(mediaType, ex.value)
+		if err != nil {
+			return nil, err
+		}
+		h.Body = b
+		hooks = append(hooks, &h)
+	}
+	return hooks, nil
+}
+
+// parameterValue returns an example value for a parameter.
+func (d *openAPI) parameterValue(param map[string]interface{}) string {
+	if v, ok := param["example"]; ok {
+		return fmt.Sprint(v)
+	}
+	exs := d.resolveMap(param["examples"])
+	for _, k := range sortedKeys(exs) {
+		if v, ok := d.resolveMap(exs[k])["value"]; ok {
+			return fmt.Sprint(v)
+		}
+	}
+	v := d.synthesize(param["schema"], 0)
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// encodeBody serializes an example value for the given media type.
+func encodeBody(mediaType string, v interface{}) (string, error) {
+	if s, ok := v.(string); ok && !strings.Contains(mediaType, "json") {
+		return s, nil
+	}
+	if strings.Contains(mediaType, "x-www-form-urlencoded") {
+		if m, ok := v.(map[string]interface{}); ok {
+			form := url.Values{}
+			for k, val := range m {
+				if s, ok := val.(string); ok {
+					form.Set(k, s)
+					continue
+				}
+				b, err := json.Marshal(val)
+				if err != nil {
+					return "", err
+				}
+				form.Set(k, string(b))
+			}
+			return form.Encode(), nil
+		}
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// synthesize generates an example value from a schema.
+func (d *openAPI) synthesize(v interface{}, depth int) interface{} {
+	if depth > maxSchemaDepth {
+		return nil
+	}
+	schema := d.resolveMap(v)
+	if schema == nil {
+		return nil
+	}
+
+	for _, key := range []string{"example", "const", "default"} {
+		if ex, ok := schema[key]; ok {
+			return ex
+		}
+	}
+	if ex, ok := schema["examples"].([]interface{}); ok && len(ex) > 0 {
+		return ex[0]
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && len(enum) > 0 {
+		return enum[0]
+	}
+	if all, ok := schema["allOf"].([]interface{}); ok {
+		out := map[string]interface{}{}
+		for _, s := range all {
+			if m, ok := d.synthesize(s, depth+1).(map[string]interface{}); ok {
+				for k, val := range m {
+					out[k] = val
+				}
+			}
+		}
+		return out
+	}
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if opts, ok := schema[key].([]interface{}); ok && len(opts) > 0 {
+			return d.synthesize(opts[0], depth+1)
+		}
+	}
+
+	switch schemaType(schema) {
+	case "object":
+		out := map[string]interface{}{}
+		props := d.resolveMap(schema["properties"])
+		for k, p := range props {
+			out[k] = d.synthesize(p, depth+1)
+		}
+		return out
+	case "array":
+		return []interface{}{d.synthesize(schema["items"], depth+1)}
+	case "string":
+		switch schema["format"] {
+		case "date-time":
+			return "2019-12-25T08:30:00Z"
+		case "date":
+			return "2019-12-25"
+		case "email":
+			return "user@example.com"
+		case "uri", "url":
+			return "https://example.com"
+		case "uuid":
+			return "00000000-0000-4000-8000-000000000000"
+		}
+		return "string"
+	case "integer", "number":
+		return 0
+	case "boolean":
+		return false
+	}
+	return nil
+}
+
+// schemaType returns the type of a schema. OpenAPI 3.1 allows a list of
+// types, in which case the first non-null type is used.
+func schemaType(schema map[string]interface{}) string {
+	switch t := schema["type"].(type) {
+	case string:
+		return t
+	case []interface{}:
+		for _, v := range t {
+			if s, ok := v.(string); ok && s != "null" {
+				return s
+			}
+		}
+	}
+	if _, ok := schema["properties"]; ok {
+		return "object"
+	}
+	return ""
+}
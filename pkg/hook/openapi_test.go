@@ -0,0 +1,102 @@
+package hook
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewFromOpenAPI(t *testing.T) {
+	f, err := os.Open(filepath.Join("testdata", "openapi.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	got, err := NewFromOpenAPI(f)
+	if err != nil {
+		t.Fatalf("NewFromOpenAPI: %v", err)
+	}
+
+	json := http.Header{"Content-Type": {"application/json"}}
+	want := []*GeneratedHooks{
+		{
+			Path: "issue-opened.yaml",
+			Hooks: []*Hook{
+				{
+					Name:    "issue/opened (labeled)",
+					Method:  http.MethodPost,
+					Headers: json,
+					Body:    `{"action":"opened","labels":["bug"],"number":2}`,
+				},
+				{
+					Name:    "issue/opened (simple)",
+					Method:  http.MethodPost,
+					Headers: json,
+					Body:    `{"action":"opened","number":1}`,
+				},
+			},
+		},
+		{
+			Path: "ping.yaml",
+			Hooks: []*Hook{{
+				Name:        "Ping",
+				Description: "Sent when a webhook is created.",
+				Method:      http.MethodPost,
+				Headers: http.Header{
+					"Content-Type": {"application/json"},
+					"X-Event":      {"ping"},
+					"X-Hook-Id":    {"0"},
+				},
+				Body: `{"active":false,"created_at":"2019-12-25T08:30:00Z","events":["push"],"hook_id":0,"sender":{"login":"octocat","site_admin":true},"zen":"string"}`,
+			}},
+		},
+		{
+			Path: "sms.yaml",
+			Hooks: []*Hook{{
+				Name:    "sms",
+				Method:  http.MethodPost,
+				Headers: http.Header{"Content-Type": {"application/x-www-form-urlencoded"}},
+				Body:    "Body=hello",
+			}},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+
+	// Generated hooks should be serializable.
+	for _, g := range got {
+		if _, err := DumpAll(g.Hooks); err != nil {
+			t.Errorf("DumpAll(%s): %v", g.Path, err)
+		}
+	}
+}
+
+func TestNewFromOpenAPICollisions(t *testing.T) {
+	doc := `openapi: 3.1.0
+webhooks:
+  order paid:
+    post: {}
+  order/paid:
+    post: {}
+  order?paid:
+    post: {}
+`
+	got, err := NewFromOpenAPI(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("NewFromOpenAPI: %v", err)
+	}
+	var paths []string
+	for _, g := range got {
+		paths = append(paths, g.Path)
+	}
+	want := []string{"order-paid.yaml", "order-paid-2.yaml", "order-paid-3.yaml"}
+	if diff := cmp.Diff(want, paths); diff != "" {
+		t.Error(diff)
+	}
+}
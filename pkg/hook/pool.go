@@ -0,0 +1,258 @@
+package hook
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Rate limits how often new requests are started.
+type Rate struct {
+	N   int
+	Per time.Duration
+}
+
+// ParseRate parses a rate of the form <count>/<unit>, e.g. "50/s", "100/m" or
+// "5/100ms".
+func ParseRate(s string) (*Rate, error) {
+	parts := strings.SplitN(s, "/", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid rate %q: expected <count>/<unit>", s)
+	}
+
+	n, err := strconv.Atoi(parts[0])
+	if err != nil || n <= 0 {
+		return nil, fmt.Errorf("invalid rate %q: count must be a positive integer", s)
+	}
+
+	var per time.Duration
+	switch parts[1] {
+	case "s":
+		per = time.Second
+	case "m":
+		per = time.Minute
+	case "h":
+		per = time.Hour
+	default:
+		per, err = time.ParseDuration(parts[1])
+		if err != nil || per <= 0 {
+			return nil, fmt.Errorf("invalid rate %q: unknown unit %q", s, parts[1])
+		}
+	}
+	return &Rate{N: n, Per: per}, nil
+}
+
+// Interval returns the time between the start of two requests.
+func (r Rate) Interval() time.Duration {
+	return r.Per / time.Duration(r.N)
+}
+
+func (r Rate) String() string {
+	return fmt.Sprintf("%d/%s", r.N, r.Per)
+}
+
+// Result is the outcome of firing a single hook.
+type Result struct {
+	// Index is the position of the hook in the fired list.
+	Index int
+	// Iteration is the repeat iteration the request belongs to.
+	Iteration int
+
+	Hook     *Hook
+	Response *http.Response
+	// Body is the fully read response body. Response.Body is already closed.
+	Body     []byte
+	Err      error
+	Duration time.Duration
+}
+
+// Summary aggregates the results of a Pool run.
+type Summary struct {
+	Total    int
+	Errors   int
+	Status   map[int]int
+	Duration time.Duration
+}
+
+func (s *Summary) add(r *Result) {
+	s.Total++
+	if r.Err != nil {
+		s.Errors++
+		return
+	}
+	s.Status[r.Response.StatusCode]++
+}
+
+// Pool fires hooks using a fixed number of workers sharing a single client.
+type Pool struct {
+	// Client is used for all requests. If nil, http.DefaultClient is used.
+	Client *http.Client
+	// Concurrency is the number of requests that may be in flight at once.
+	// Values less than 1 are treated as 1.
+	Concurrency int
+	// Repeat is the number of times the full list of hooks is fired.
+	// Values less than 1 are treated as 1.
+	Repeat int
+	// Rate optionally limits how quickly requests are started.
+	Rate *Rate
+	// Duration, if set, fires the hooks repeatedly until it has elapsed.
+	// Repeat is ignored.
+	Duration time.Duration
+	// PreserveTiming waits the recorded gaps (see Hook.ReceivedAt) between
+	// hooks before starting each request.
+	PreserveTiming bool
+	// Speed divides the recorded gaps when PreserveTiming is set, e.g. 2
+	// replays twice as fast. Values less than or equal to 0 are treated as 1.
+	Speed float64
+}
+
+type job struct {
+	index     int
+	iteration int
+	hook      *Hook
+}
+
+// Fire fires every hook Repeat times (or until Duration has elapsed) against
+// target. handle is called once per request, never concurrently, so it may
+// write output without synchronization.
+func (p *Pool) Fire(target string, hooks []*Hook, handle func(*Result)) *Summary {
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	workers := p.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+	repeat := p.Repeat
+	if repeat < 1 {
+		repeat = 1
+	}
+
+	start := time.Now()
+	jobs := make(chan job)
+	results := make(chan *Result)
+
+	go func() {
+		defer close(jobs)
+		if len(hooks) == 0 {
+			return
+		}
+		var tick <-chan time.Time
+		if p.Rate != nil {
+			t := time.NewTicker(p.Rate.Interval())
+			defer t.Stop()
+			tick = t.C
+		}
+		var deadline time.Time
+		if p.Duration > 0 {
+			deadline = start.Add(p.Duration)
+		}
+		var offsets []time.Duration
+		if p.PreserveTiming {
+			offsets = Offsets(hooks, p.Speed)
+		}
+		first := true
+		for i := 0; !deadline.IsZero() || i < repeat; i++ {
+			iterStart := time.Now()
+			for n, h := range hooks {
+				if tick != nil && !first {
+					<-tick
+				}
+				first = false
+				if offsets != nil {
+					time.Sleep(time.Until(iterStart.Add(offsets[n])))
+				}
+				if !deadline.IsZero() && time.Now().After(deadline) {
+					return
+				}
+				jobs <- job{index: n, iteration: i, hook: h}
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				results <- fireJob(client, target, j)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	summary := &Summary{Status: make(map[int]int)}
+	for r := range results {
+		summary.add(r)
+		if handle != nil {
+			handle(r)
+		}
+	}
+	summary.Duration = time.Since(start)
+	return summary
+}
+
+// Offsets returns when each hook should be fired relative to the first,
+// based on when the hooks were recorded and divided by speed. Hooks without a
+// recorded time are fired immediately after the previous hook.
+func Offsets(hooks []*Hook, speed float64) []time.Duration {
+	if speed <= 0 {
+		speed = 1
+	}
+	out := make([]time.Duration, len(hooks))
+	var first time.Time
+	for i, h := range hooks {
+		if i > 0 {
+			out[i] = out[i-1]
+		}
+		if h.ReceivedAt.IsZero() {
+			continue
+		}
+		if first.IsZero() {
+			first = h.ReceivedAt
+			continue
+		}
+		if d := time.Duration(float64(h.ReceivedAt.Sub(first)) / speed); d > out[i] {
+			out[i] = d
+		}
+	}
+	return out
+}
+
+// ParseSpeed parses a replay speed multiplier such as "2x", "0.5x" or "2".
+func ParseSpeed(s string) (float64, error) {
+	f, err := strconv.ParseFloat(strings.TrimSuffix(s, "x"), 64)
+	if err != nil || f <= 0 {
+		return 0, fmt.Errorf("invalid speed %q: expected a positive multiplier such as 2x", s)
+	}
+	return f, nil
+}
+
+func fireJob(client *http.Client, target string, j job) *Result {
+	r := &Result{
+		Index:     j.index,
+		Iteration: j.iteration,
+		Hook:      j.hook,
+	}
+	start := time.Now()
+	res, err := j.hook.FireWithClient(client, target)
+	if err != nil {
+		r.Err = err
+		r.Duration = time.Since(start)
+		return r
+	}
+	defer res.Body.Close()
+	r.Response = res
+	r.Body, r.Err = ioutil.ReadAll(res.Body)
+	r.Duration = time.Since(start)
+	return r
+}
@@ -0,0 +1,222 @@
+package hook
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want *Rate
+	}{
+		{
+			in:   "50/s",
+			want: &Rate{N: 50, Per: time.Second},
+		},
+		{
+			in:   "100/m",
+			want: &Rate{N: 100, Per: time.Minute},
+		},
+		{
+			in:   "1/h",
+			want: &Rate{N: 1, Per: time.Hour},
+		},
+		{
+			in:   "5/100ms",
+			want: &Rate{N: 5, Per: 100 * time.Millisecond},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := ParseRate(tc.in)
+			if err != nil {
+				t.Fatalf("ParseRate(%s): %v", tc.in, err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+
+	for _, in := range []string{"", "50", "0/s", "-1/s", "a/s", "5/fortnight"} {
+		if _, err := ParseRate(in); err == nil {
+			t.Errorf("ParseRate(%s): expected error", in)
+		}
+	}
+}
+
+// concurrencyServer tracks the number of requests it has seen, the bodies it
+// received and the maximum number of requests handled at once.
+type concurrencyServer struct {
+	mu       sync.Mutex
+	inflight int
+	max      int
+	bodies   []string
+}
+
+func (s *concurrencyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b, _ := ioutil.ReadAll(r.Body)
+
+	s.mu.Lock()
+	s.inflight++
+	if s.inflight > s.max {
+		s.max = s.inflight
+	}
+	s.bodies = append(s.bodies, string(b))
+	s.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	s.mu.Lock()
+	s.inflight--
+	s.mu.Unlock()
+
+	w.Write(b)
+}
+
+func TestPoolFire(t *testing.T) {
+	s := &concurrencyServer{}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	hooks := []*Hook{
+		{
+			Method:    http.MethodPost,
+			Body:      `{"foo":"bar"}`,
+			Transform: map[TransformStrategy][]string{TransformBase64: {"foo"}},
+		},
+		{
+			Method: http.MethodPost,
+			Body:   "tacocat",
+		},
+	}
+
+	p := &Pool{
+		Client:      srv.Client(),
+		Concurrency: 3,
+		Repeat:      5,
+	}
+	var results []*Result
+	summary := p.Fire(srv.URL, hooks, func(r *Result) {
+		results = append(results, r)
+	})
+
+	if summary.Total != 10 || summary.Errors != 0 {
+		t.Errorf("summary: got %d total, %d errors, want 10 total, 0 errors", summary.Total, summary.Errors)
+	}
+	if summary.Status[http.StatusOK] != 10 {
+		t.Errorf("summary: got %d 200 responses, want 10", summary.Status[http.StatusOK])
+	}
+	if len(results) != 10 {
+		t.Fatalf("got %d results, want 10", len(results))
+	}
+	if s.max > 3 {
+		t.Errorf("got %d concurrent requests, want at most 3", s.max)
+	}
+
+	// Firing the same hook repeatedly must not re-apply transforms.
+	for _, r := range results {
+		want := "tacocat"
+		if r.Index == 0 {
+			want = `{"foo":"YmFy"}`
+		}
+		if string(r.Body) != want {
+			t.Errorf("result %d/%d: got body %s, want %s", r.Iteration, r.Index, r.Body, want)
+		}
+	}
+	if hooks[0].Body != `{"foo":"bar"}` {
+		t.Errorf("hook body was modified: %s", hooks[0].Body)
+	}
+}
+
+func TestPoolFireRate(t *testing.T) {
+	s := &concurrencyServer{}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	p := &Pool{
+		Client:      srv.Client(),
+		Concurrency: 4,
+		Repeat:      4,
+		Rate:        &Rate{N: 1, Per: 20 * time.Millisecond},
+	}
+	summary := p.Fire(srv.URL, []*Hook{{Method: http.MethodGet}}, nil)
+	if summary.Total != 4 {
+		t.Errorf("got %d requests, want 4", summary.Total)
+	}
+	// 4 requests at 1 per 20ms should take at least 60ms to start.
+	if summary.Duration < 60*time.Millisecond {
+		t.Errorf("requests finished in %s, expected rate limiting", summary.Duration)
+	}
+}
+
+func TestOffsets(t *testing.T) {
+	base := time.Date(2019, 12, 25, 8, 30, 0, 0, time.UTC)
+	hooks := []*Hook{
+		{ReceivedAt: base},
+		{ReceivedAt: base.Add(2 * time.Second)},
+		{},
+		{ReceivedAt: base.Add(3 * time.Second)},
+		// Out of order timestamps should never move a hook earlier.
+		{ReceivedAt: base.Add(time.Second)},
+	}
+
+	want := []time.Duration{0, time.Second, time.Second, 1500 * time.Millisecond, 1500 * time.Millisecond}
+	if diff := cmp.Diff(want, Offsets(hooks, 2)); diff != "" {
+		t.Error(diff)
+	}
+
+	want = []time.Duration{0, 2 * time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second}
+	if diff := cmp.Diff(want, Offsets(hooks, 0)); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestParseSpeed(t *testing.T) {
+	for in, want := range map[string]float64{"2x": 2, "0.5x": 0.5, "3": 3} {
+		got, err := ParseSpeed(in)
+		if err != nil {
+			t.Fatalf("ParseSpeed(%s): %v", in, err)
+		}
+		if got != want {
+			t.Errorf("ParseSpeed(%s): got %v, want %v", in, got, want)
+		}
+	}
+	for _, in := range []string{"", "x", "0x", "-1x", "fast"} {
+		if _, err := ParseSpeed(in); err == nil {
+			t.Errorf("ParseSpeed(%s): expected error", in)
+		}
+	}
+}
+
+func TestPoolFirePreserveTiming(t *testing.T) {
+	s := &concurrencyServer{}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	base := time.Now()
+	hooks := []*Hook{
+		{Method: http.MethodGet, ReceivedAt: base},
+		{Method: http.MethodGet, ReceivedAt: base.Add(200 * time.Millisecond)},
+	}
+	p := &Pool{
+		Client:         srv.Client(),
+		PreserveTiming: true,
+		Speed:          2,
+	}
+	summary := p.Fire(srv.URL, hooks, nil)
+	if summary.Total != 2 {
+		t.Errorf("got %d requests, want 2", summary.Total)
+	}
+	// The second hook should be delayed by 200ms / 2.
+	if summary.Duration < 100*time.Millisecond {
+		t.Errorf("requests finished in %s, expected at least 100ms", summary.Duration)
+	}
+}
@@ -0,0 +1,548 @@
+package hook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// PostmanSchema is the Postman collection format supported.
+const PostmanSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// PostmanCollection is a Postman collection (v2.1). Only the fields relevant to
+// hooks are included.
+type PostmanCollection struct {
+	Info     *PostmanInfo       `json:"info"`
+	Item     []*PostmanItem     `json:"item"`
+	Variable []*PostmanKeyValue `json:"variable,omitempty"`
+}
+
+// PostmanInfo describes the collection.
+type PostmanInfo struct {
+	Name        string             `json:"name"`
+	Description PostmanDescription `json:"description,omitempty"`
+	Schema      string             `json:"schema"`
+}
+
+// PostmanItem is either a request or, if Item is set, a folder.
+type PostmanItem struct {
+	Name        string             `json:"name"`
+	Description PostmanDescription `json:"description,omitempty"`
+	Item        []*PostmanItem     `json:"item,omitempty"`
+	Request     *PostmanRequest    `json:"request,omitempty"`
+}
+
+// PostmanRequest is a single request.
+type PostmanRequest struct {
+	Method      string             `json:"method"`
+	Header      []*PostmanKeyValue `json:"header"`
+	Body        *PostmanBody       `json:"body,omitempty"`
+	URL         *PostmanURL        `json:"url"`
+	Description PostmanDescription `json:"description,omitempty"`
+}
+
+// UnmarshalJSON handles requests given as a plain URL string.
+func (r *PostmanRequest) UnmarshalJSON(b []byte) error {
+	var s string
+	if json.Unmarshal(b, &s) == nil {
+		*r = PostmanRequest{Method: http.MethodGet, URL: &PostmanURL{Raw: s}}
+		return nil
+	}
+	type request PostmanRequest
+	return json.Unmarshal(b, (*request)(r))
+}
+
+// PostmanURL is a request URL.
+type PostmanURL struct {
+	Raw   string             `json:"raw"`
+	Query []*PostmanKeyValue `json:"query,omitempty"`
+}
+
+// UnmarshalJSON handles URLs given as a plain string.
+func (u *PostmanURL) UnmarshalJSON(b []byte) error {
+	var s string
+	if json.Unmarshal(b, &s) == nil {
+		*u = PostmanURL{Raw: s}
+		return nil
+	}
+	type postmanURL PostmanURL
+	return json.Unmarshal(b, (*postmanURL)(u))
+}
+
+// PostmanBody is a request body.
+type PostmanBody struct {
+	Mode       string             `json:"mode"`
+	Raw        string             `json:"raw,omitempty"`
+	URLEncoded []*PostmanKeyValue `json:"urlencoded,omitempty"`
+	FormData   []*PostmanKeyValue `json:"formdata,omitempty"`
+	GraphQL    *struct {
+		Query     string `json:"query"`
+		Variables string `json:"variables,omitempty"`
+	} `json:"graphql,omitempty"`
+	Options *struct {
+		Raw struct {
+			Language string `json:"language"`
+		} `json:"raw"`
+	} `json:"options,omitempty"`
+}
+
+// PostmanKeyValue is a header, query parameter, form field or variable.
+type PostmanKeyValue struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Type     string `json:"type,omitempty"`
+	Disabled bool   `json:"disabled,omitempty"`
+}
+
+// PostmanDescription is a description, which Postman allows to be either a
+// string or an object with content.
+type PostmanDescription string
+
+// UnmarshalJSON handles both description forms.
+func (d *PostmanDescription) UnmarshalJSON(b []byte) error {
+	var s string
+	if json.Unmarshal(b, &s) == nil {
+		*d = PostmanDescription(s)
+		return nil
+	}
+	var obj struct {
+		Content string `json:"content"`
+	}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return err
+	}
+	*d = PostmanDescription(obj.Content)
+	return nil
+}
+
+var (
+	// postmanVar matches Postman {{variable}} references.
+	postmanVar = regexp.MustCompile(`{{\s*([^{}\s]+)\s*}}`)
+	// templateVar matches the simple hook templates that can be converted back
+	// into Postman variables.
+	templateVar = regexp.MustCompile(`{{\s*(?:\.([A-Za-z_][A-Za-z0-9_]*)|index \. "([^"]+)"|(uuid|unix))\s*}}`)
+	identifier  = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+	// postmanDynamic maps Postman dynamic variables to template functions.
+	postmanDynamic = map[string]string{
+		"$guid":         "uuid",
+		"$randomUUID":   "uuid",
+		"$timestamp":    "unix",
+		"$randomInt":    "randInt 0 1000",
+		"$isoTimestamp": "now",
+	}
+	// templateDynamic maps template functions to Postman dynamic variables.
+	templateDynamic = map[string]string{
+		"uuid": "$guid",
+		"unix": "$timestamp",
+	}
+)
+
+// fromPostmanVars converts Postman {{variable}} references into hook
+// templates.
+func fromPostmanVars(s string) string {
+	return postmanVar.ReplaceAllStringFunc(s, func(m string) string {
+		name := postmanVar.FindStringSubmatch(m)[1]
+		if fn, ok := postmanDynamic[name]; ok {
+			return "{{ " + fn + " }}"
+		}
+		if identifier.MatchString(name) {
+			return "{{ ." + name + " }}"
+		}
+		return fmt.Sprintf("{{ index . %q }}", name)
+	})
+}
+
+// escapePostmanVars query escapes s and converts its Postman variables into
+// templates. Variables are converted first, as escaping would break their
+// references.
+func escapePostmanVars(s string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range postmanVar.FindAllStringIndex(s, -1) {
+		b.WriteString(url.QueryEscape(s[last:m[0]]))
+		b.WriteString(fromPostmanVars(s[m[0]:m[1]]))
+		last = m[1]
+	}
+	b.WriteString(url.QueryEscape(s[last:]))
+	return b.String()
+}
+
+// toPostmanVars converts hook templates into Postman {{variable}} references.
+// Templates without a Postman equivalent are left as is.
+func toPostmanVars(s string) string {
+	return templateVar.ReplaceAllStringFunc(s, func(m string) string {
+		sub := templateVar.FindStringSubmatch(m)
+		switch {
+		case sub[1] != "":
+			return "{{" + sub[1] + "}}"
+		case sub[2] != "":
+			return "{{" + sub[2] + "}}"
+		default:
+			return "{{" + templateDynamic[sub[3]] + "}}"
+		}
+	})
+}
+
+// NewFromPostman generates hook files from a Postman collection (v2.1).
+// Folders become directories and collection variables become hook variables.
+func NewFromPostman(r io.Reader) ([]*GeneratedHooks, error) {
+	c := new(PostmanCollection)
+	if err := json.NewDecoder(r).Decode(c); err != nil {
+		return nil, err
+	}
+	if c.Info == nil {
+		return nil, errors.New("invalid Postman collection: missing info")
+	}
+
+	var vars map[string]string
+	for _, v := range c.Variable {
+		if v.Disabled {
+			continue
+		}
+		if vars == nil {
+			vars = make(map[string]string)
+		}
+		vars[v.Key] = v.Value
+	}
+
+	var res []*GeneratedHooks
+	seen := make(map[string]int)
+	var walk func(dir string, items []*PostmanItem) error
+	walk = func(dir string, items []*PostmanItem) error {
+		for _, it := range items {
+			name := fileName(it.Name)
+			if name == "" {
+				name = "request"
+			}
+			if it.Request == nil {
+				if err := walk(filepath.Join(dir, name), it.Item); err != nil {
+					return err
+				}
+				continue
+			}
+
+			h, err := hookFromPostman(it)
+			if err != nil {
+				return fmt.Errorf("%s: %v", it.Name, err)
+			}
+			if len(vars) > 0 {
+				h.Vars = make(map[string]string, len(vars))
+				for k, v := range vars {
+					h.Vars[k] = v
+				}
+			} else {
+				// Postman variables were converted into templates.
+				h.Template = h.hasTemplates()
+			}
+
+			// Postman allows duplicate names, files don't.
+			path := filepath.Join(dir, name)
+			seen[path]++
+			if n := seen[path]; n > 1 {
+				path = fmt.Sprintf("%s-%d", path, n)
+			}
+			res = append(res, &GeneratedHooks{Path: path + ".yaml", Hooks: []*Hook{h}})
+		}
+		return nil
+	}
+	if err := walk("", c.Item); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func hookFromPostman(it *PostmanItem) (*Hook, error) {
+	r := it.Request
+	h := &Hook{
+		Name:        it.Name,
+		Description: strings.TrimSpace(string(it.Description)),
+		Method:      strings.ToUpper(r.Method),
+	}
+	if h.Method == "" {
+		h.Method = http.MethodGet
+	}
+	if h.Description == "" {
+		h.Description = strings.TrimSpace(string(r.Description))
+	}
+
+	for _, kv := range r.Header {
+		if kv.Disabled {
+			continue
+		}
+		if h.Headers == nil {
+			h.Headers = make(http.Header)
+		}
+		h.Headers.Add(kv.Key, fromPostmanVars(kv.Value))
+	}
+
+	if r.URL != nil {
+		query := r.URL.Query
+		if query == nil {
+			// Fall back to parsing the raw URL, which may contain variables.
+			if i := strings.Index(r.URL.Raw, "?"); i >= 0 {
+				for _, p := range strings.Split(r.URL.Raw[i+1:], "&") {
+					kv := strings.SplitN(p, "=", 2)
+					k, _ := url.QueryUnescape(kv[0])
+					v := ""
+					if len(kv) == 2 {
+						v, _ = url.QueryUnescape(kv[1])
+					}
+					query = append(query, &PostmanKeyValue{Key: k, Value: v})
+				}
+			}
+		}
+		for _, kv := range query {
+			if kv.Disabled || kv.Key == "" {
+				continue
+			}
+			if h.Params == nil {
+				h.Params = make(url.Values)
+			}
+			h.Params.Add(kv.Key, fromPostmanVars(kv.Value))
+		}
+	}
+
+	if r.Body == nil {
+		return h, nil
+	}
+	setContentType := func(ct string) {
+		if h.Headers == nil {
+			h.Headers = make(http.Header)
+		}
+		setDefaultHeader(h.Headers, "Content-Type", ct)
+	}
+	switch r.Body.Mode {
+	case "raw":
+		h.Body = fromPostmanVars(r.Body.Raw)
+		if r.Body.Options != nil && r.Body.Options.Raw.Language == "json" {
+			setContentType("application/json")
+		}
+	case "urlencoded":
+		var parts []string
+		for _, kv := range r.Body.URLEncoded {
+			if kv.Disabled {
+				continue
+			}
+			parts = append(parts, escapePostmanVars(kv.Key)+"="+escapePostmanVars(kv.Value))
+		}
+		h.Body = strings.Join(parts, "&")
+		setContentType("application/x-www-form-urlencoded")
+	case "formdata":
+		var fields []string
+		for _, kv := range r.Body.FormData {
+			if kv.Disabled {
+				continue
+			}
+			if kv.Type == "file" {
+				return nil, fmt.Errorf("form file %s is not supported", kv.Key)
+			}
+			fields = append(fields, kv.Key+"="+kv.Value)
+		}
+		body, err := curlForm(fields)
+		if err != nil {
+			return nil, err
+		}
+		h.Body = fromPostmanVars(body)
+		if h.Headers == nil {
+			h.Headers = make(http.Header)
+		}
+		h.Headers.Set("Content-Type", "multipart/form-data; boundary="+formBoundary)
+	case "graphql":
+		if r.Body.GraphQL != nil {
+			q := map[string]interface{}{"query": r.Body.GraphQL.Query}
+			if v := r.Body.GraphQL.Variables; v != "" {
+				q["variables"] = json.RawMessage(v)
+			}
+			b, err := json.Marshal(q)
+			if err != nil {
+				return nil, err
+			}
+			h.Body = fromPostmanVars(string(b))
+			setContentType("application/json")
+		}
+	}
+	return h, nil
+}
+
+// NewPostmanCollection returns an empty collection with the given name.
+func NewPostmanCollection(name string) *PostmanCollection {
+	return &PostmanCollection{
+		Info: &PostmanInfo{
+			Name:   name,
+			Schema: PostmanSchema,
+		},
+		Item: []*PostmanItem{},
+	}
+}
+
+// Write writes the collection as indented JSON.
+func (c *PostmanCollection) Write(w io.Writer) error {
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return e.Encode(c)
+}
+
+// folder returns the folder at the given path, creating it if needed.
+func (c *PostmanCollection) folder(path []string) *PostmanItem {
+	root := &PostmanItem{Item: c.Item}
+	cur := root
+	for _, name := range path {
+		var next *PostmanItem
+		for _, it := range cur.Item {
+			if it.Request == nil && it.Name == name {
+				next = it
+				break
+			}
+		}
+		if next == nil {
+			next = &PostmanItem{Name: name, Item: []*PostmanItem{}}
+			cur.Item = append(cur.Item, next)
+		}
+		cur = next
+	}
+	c.Item = root.Item
+	return cur
+}
+
+// AddHooks adds hooks to the folder at path (which may be empty for the top
+// level). Items are named after the hook, or name if the hook has none. Hook
+// variables are added as collection variables.
+func (c *PostmanCollection) AddHooks(path []string, name, target string, hooks []*Hook) error {
+	u, err := url.Parse(target)
+	if err != nil {
+		return err
+	}
+
+	for i, h := range hooks {
+		it := &PostmanItem{
+			Name: h.Name,
+			Request: &PostmanRequest{
+				Method:      h.Method,
+				Header:      []*PostmanKeyValue{},
+				Description: PostmanDescription(h.Description),
+			},
+		}
+		if it.Name == "" {
+			it.Name = name
+			if len(hooks) > 1 {
+				it.Name = fmt.Sprintf("%s %d", name, i+1)
+			}
+		}
+
+		keys := make([]string, 0, len(h.Headers))
+		for k := range h.Headers {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if skipExportHeader[http.CanonicalHeaderKey(k)] {
+				continue
+			}
+			for _, v := range h.Headers[k] {
+				it.Request.Header = append(it.Request.Header, &PostmanKeyValue{Key: k, Value: toPostmanVars(v)})
+			}
+		}
+
+		pu := &PostmanURL{}
+		var raw []string
+		for _, kv := range harNameValues(h.Params) {
+			v := toPostmanVars(kv.Value)
+			pu.Query = append(pu.Query, &PostmanKeyValue{Key: kv.Name, Value: v})
+			raw = append(raw, url.QueryEscape(kv.Name)+"="+v)
+		}
+		base := *u
+		base.RawQuery = ""
+		pu.Raw = base.String()
+		if len(raw) > 0 {
+			pu.Raw += "?" + strings.Join(raw, "&")
+		}
+		it.Request.URL = pu
+
+		if h.Body != "" {
+			// Postman can't apply transforms, so they are applied here. That
+			// isn't possible for templated bodies, which must be rendered first.
+			if len(h.Transform) > 0 && isTemplate(h.Body) {
+				return fmt.Errorf("%s: templated hooks with transforms cannot be exported to Postman", it.Name)
+			}
+			body, err := h.encodedBody()
+			if err != nil {
+				return err
+			}
+			it.Request.Body = &PostmanBody{Mode: "raw", Raw: toPostmanVars(body)}
+		}
+
+		for k, v := range h.Vars {
+			c.setVariable(k, v)
+		}
+
+		f := c.folder(path)
+		f.Item = append(f.Item, it)
+		if len(path) == 0 {
+			c.Item = f.Item
+		}
+	}
+	return nil
+}
+
+func (c *PostmanCollection) setVariable(key, value string) {
+	for _, v := range c.Variable {
+		if v.Key == key {
+			return
+		}
+	}
+	c.Variable = append(c.Variable, &PostmanKeyValue{Key: key, Value: value})
+}
+
+// AddDir adds every hook file below dir, with subdirectories as folders.
+func (c *PostmanCollection) AddDir(dir, target string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !IsHookFile(path) {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		hooks, err := New(strings.NewReader(string(b)))
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+
+		var folders []string
+		if d := filepath.Dir(rel); d != "." {
+			folders = strings.Split(filepath.ToSlash(d), "/")
+		}
+		name := strings.TrimSuffix(filepath.Base(rel), filepath.Ext(rel))
+		return c.AddHooks(folders, name, target, hooks)
+	})
+}
+
+// PostmanExporter exports hooks as a Postman collection (v2.1). Templates are
+// converted into Postman variables where possible.
+type PostmanExporter struct{}
+
+// Export writes all hooks as items of a single collection.
+func (PostmanExporter) Export(w io.Writer, target string, hooks []*Hook) error {
+	c := NewPostmanCollection(AppName)
+	if err := c.AddHooks(nil, "hook", target, hooks); err != nil {
+		return err
+	}
+	return c.Write(w)
+}
@@ -0,0 +1,160 @@
+package hook
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewFromPostman(t *testing.T) {
+	f, err := os.Open(filepath.Join("testdata", "postman.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	got, err := NewFromPostman(f)
+	if err != nil {
+		t.Fatalf("NewFromPostman: %v", err)
+	}
+
+	vars := map[string]string{"amount": "100", "account-id": "acct_1", "mode": "test"}
+	want := []*GeneratedHooks{
+		{
+			Path: filepath.Join("Payments", "charge.succeeded.yaml"),
+			Hooks: []*Hook{{
+				Name:        "charge.succeeded",
+				Description: "A charge succeeded.",
+				Method:      http.MethodPost,
+				Headers: http.Header{
+					"Content-Type":     {"application/json"},
+					"Stripe-Signature": {"{{ .signature }}"},
+				},
+				Body:   `{"id":"{{ uuid }}","amount":{{ .amount }},"account":"{{ index . "account-id" }}"}`,
+				Params: url.Values{"mode": {"{{ .mode }}"}},
+				Vars:   vars,
+			}},
+		},
+		{
+			Path:  "Ping.yaml",
+			Hooks: []*Hook{{Name: "Ping", Method: http.MethodGet, Vars: vars}},
+		},
+		{
+			Path: "Form.yaml",
+			Hooks: []*Hook{{
+				Name:    "Form",
+				Method:  http.MethodPost,
+				Headers: http.Header{"Content-Type": {"application/x-www-form-urlencoded"}},
+				Body:    "Body=hello+world&Mode={{ .mode }}+%26+live",
+				Vars:    vars,
+			}},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+
+	// Imported hooks render with the collection variables.
+	h := got[0].Hooks[0]
+	h.Vars["signature"] = "sig"
+	r, err := h.render()
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if r.Params.Get("mode") != "test" || r.Headers.Get("Stripe-Signature") != "sig" {
+		t.Errorf("render: got %+v", r)
+	}
+}
+
+func TestPostmanExporter(t *testing.T) {
+	h := &Hook{
+		Name:    "push",
+		Method:  http.MethodPost,
+		Headers: http.Header{"Content-Type": {"application/json"}, "Content-Length": {"10"}},
+		Body:    `{"id":"{{ uuid }}","ref":"{{ .ref }}","n":{{ randInt 1 5 }}}`,
+		Params:  url.Values{"a": {"{{ index . \"a-b\" }}"}},
+		Vars:    map[string]string{"ref": "master"},
+	}
+
+	b := new(bytes.Buffer)
+	if err := (PostmanExporter{}).Export(b, "http://localhost:8080/hook?x=y", []*Hook{h}); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	c := new(PostmanCollection)
+	if err := json.Unmarshal(b.Bytes(), c); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	want := &PostmanCollection{
+		Info: &PostmanInfo{Name: AppName, Schema: PostmanSchema},
+		Item: []*PostmanItem{{
+			Name: "push",
+			Request: &PostmanRequest{
+				Method: http.MethodPost,
+				Header: []*PostmanKeyValue{{Key: "Content-Type", Value: "application/json"}},
+				Body:   &PostmanBody{Mode: "raw", Raw: `{"id":"{{$guid}}","ref":"{{ref}}","n":{{ randInt 1 5 }}}`},
+				URL: &PostmanURL{
+					Raw:   "http://localhost:8080/hook?a={{a-b}}",
+					Query: []*PostmanKeyValue{{Key: "a", Value: "{{a-b}}"}},
+				},
+			},
+		}},
+		Variable: []*PostmanKeyValue{{Key: "ref", Value: "master"}},
+	}
+	if diff := cmp.Diff(want, c); diff != "" {
+		t.Error(diff)
+	}
+
+	// Exported collections can be imported again.
+	files, err := NewFromPostman(b)
+	if err != nil {
+		t.Fatalf("NewFromPostman: %v", err)
+	}
+	if got := files[0].Hooks[0].Body; got != `{"id":"{{ uuid }}","ref":"{{ .ref }}","n":{{ randInt 1 5 }}}` {
+		t.Errorf("round trip: got body %s", got)
+	}
+}
+
+func TestPostmanCollectionAddDir(t *testing.T) {
+	d, err := ioutil.TempDir("", "hook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d)
+	for _, path := range []string{"ping.yaml", filepath.Join("orders", "created.yml"), filepath.Join("orders", "README.md")} {
+		if err := os.MkdirAll(filepath.Join(d, filepath.Dir(path)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(d, path), []byte("method: POST\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := NewPostmanCollection("catalog")
+	if err := c.AddDir(d, "http://localhost"); err != nil {
+		t.Fatalf("AddDir: %v", err)
+	}
+
+	var names []string
+	var walk func(prefix string, items []*PostmanItem)
+	walk = func(prefix string, items []*PostmanItem) {
+		for _, it := range items {
+			if it.Request == nil {
+				walk(prefix+it.Name+"/", it.Item)
+				continue
+			}
+			names = append(names, prefix+it.Name)
+		}
+	}
+	walk("", c.Item)
+	if diff := cmp.Diff([]string{"orders/created", "ping"}, names); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,171 @@
+package hook
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SchemaURL is where the JSON Schema of hook documents is published.
+const SchemaURL = "https://raw.githubusercontent.com/eddiezane/hook/master/schema/hook.schema.json"
+
+// SchemaModeline is the comment Dump starts documents with, which tells
+// editors using yaml-language-server which schema applies to a file.
+const SchemaModeline = "# yaml-language-server: $schema=" + SchemaURL + "\n"
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	envelopeType = reflect.TypeOf(&Envelope{})
+)
+
+// Schema returns the JSON Schema of the current hook document version,
+// generated from the document types.
+func Schema() ([]byte, error) {
+	s := schemaFor(reflect.TypeOf(hookV1{}))
+	s["$schema"] = "http://json-schema.org/draft-07/schema#"
+	s["$id"] = SchemaURL
+	s["title"] = "Hook " + APIVersion + " document"
+
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// schemaFor returns the schema of a Go type.
+func schemaFor(t reflect.Type) map[string]interface{} {
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case envelopeType:
+		return envelopeSchema()
+	case reflect.TypeOf(CloudEventsMode("")):
+		return map[string]interface{}{"type": "string", "enum": []string{string(CloudEventsBinary), string(CloudEventsStructured)}}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return schemaFor(t.Elem())
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": schemaFor(t.Elem())}
+	case reflect.Map:
+		s := map[string]interface{}{"type": "object", "additionalProperties": schemaFor(t.Elem())}
+		if t.Key() == reflect.TypeOf(TransformStrategy("")) {
+			s["propertyNames"] = map[string]interface{}{"enum": sortedTransformers()}
+		}
+		return s
+	case reflect.Struct:
+		return structSchema(t)
+	}
+	panic(fmt.Sprintf("no schema for type %v", t))
+}
+
+// structSchema returns the schema of a struct using its yaml tags. Inlined
+// structs are flattened and fields without omitempty are required.
+func structSchema(t reflect.Type) map[string]interface{} {
+	props := make(map[string]interface{})
+	var required []string
+
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := strings.Split(f.Tag.Get("yaml"), ",")
+			if len(tag) > 1 && tag[1] == "inline" {
+				walk(f.Type)
+				continue
+			}
+			name := tag[0]
+			if name == "" {
+				name = strings.ToLower(f.Name)
+			}
+			if name == "-" {
+				continue
+			}
+
+			s := schemaFor(f.Type)
+			if d := f.Tag.Get("description"); d != "" {
+				s["description"] = d
+			}
+			props[name] = s
+			if len(tag) < 2 || tag[1] != "omitempty" {
+				required = append(required, name)
+			}
+		}
+	}
+	walk(t)
+
+	// The version header and method have fixed values.
+	if p, ok := props["apiVersion"].(map[string]interface{}); ok {
+		p["const"] = APIVersion
+	}
+	if p, ok := props["kind"].(map[string]interface{}); ok {
+		p["const"] = KindHook
+	}
+	if p, ok := props["method"].(map[string]interface{}); ok {
+		p["enum"] = sortedMethods()
+	}
+	if p, ok := props["algorithm"].(map[string]interface{}); ok {
+		p["enum"] = sortedAlgorithms()
+	}
+	if p, ok := props["encoding"].(map[string]interface{}); ok {
+		p["enum"] = []string{"base64", "hex"}
+	}
+
+	sort.Strings(required)
+	s := map[string]interface{}{
+		"type":                 "object",
+		"properties":           props,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+// envelopeSchema allows both the scalar and the mapping forms of envelopes.
+func envelopeSchema() map[string]interface{} {
+	names := make([]string, 0, len(Envelopes))
+	for n := range Envelopes {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	full := structSchema(reflect.TypeOf(Envelope{}))
+	full["properties"].(map[string]interface{})["type"].(map[string]interface{})["enum"] = names
+	return map[string]interface{}{
+		"oneOf": []interface{}{
+			map[string]interface{}{"type": "string", "enum": names},
+			full,
+		},
+	}
+}
+
+func sortedTransformers() []string {
+	names := make([]string, 0, len(Transformers))
+	for t := range Transformers {
+		names = append(names, string(t))
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedMethods() []string {
+	names := make([]string, 0, len(methods))
+	for m := range methods {
+		names = append(names, m)
+	}
+	sort.Strings(names)
+	return names
+}
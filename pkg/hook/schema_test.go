@@ -0,0 +1,54 @@
+package hook
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSchemaInSync(t *testing.T) {
+	want, err := ioutil.ReadFile(filepath.Join("..", "..", "schema", "hook.schema.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Schema()
+	if err != nil {
+		t.Fatalf("Schema: %v", err)
+	}
+	if diff := cmp.Diff(string(want), string(got)); diff != "" {
+		t.Errorf("schema/hook.schema.json is out of date, run go run . in tools/schemagen:\n%s", diff)
+	}
+}
+
+func TestSchema(t *testing.T) {
+	b, err := Schema()
+	if err != nil {
+		t.Fatalf("Schema: %v", err)
+	}
+	var s struct {
+		Required   []string                   `json:"required"`
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	if err := json.Unmarshal(b, &s); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	if diff := cmp.Diff([]string{"apiVersion", "kind", "method"}, s.Required); diff != "" {
+		t.Errorf("required: %s", diff)
+	}
+	// Every document field is described.
+	for name, p := range s.Properties {
+		var prop struct {
+			Description string `json:"description"`
+		}
+		if err := json.Unmarshal(p, &prop); err != nil {
+			t.Fatal(err)
+		}
+		if prop.Description == "" {
+			t.Errorf("property %s has no description", name)
+		}
+	}
+}
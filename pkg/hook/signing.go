@@ -0,0 +1,154 @@
+package hook
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	// SigningAlgorithms are the supported HMAC hash functions.
+	SigningAlgorithms = map[string]func() hash.Hash{
+		"sha1":   sha1.New,
+		"sha256": sha256.New,
+		"sha512": sha512.New,
+	}
+
+	// signingEncodings encode signatures.
+	signingEncodings = map[string]func([]byte) string{
+		"hex":    hex.EncodeToString,
+		"base64": base64.StdEncoding.EncodeToString,
+	}
+)
+
+// Signing signs the body of a hook with an HMAC when it is fired, like
+// GitHub's X-Hub-Signature-256 or Shopify's X-Shopify-Hmac-Sha256:
+//
+//	signing:
+//	  header: X-Hub-Signature-256
+//	  prefix: sha256=
+//	  secret: '{{ env "GITHUB_WEBHOOK_SECRET" }}'
+type Signing struct {
+	Header    string `yaml:"header" json:"header" description:"Header to send the signature in."`
+	Secret    string `yaml:"secret" json:"secret" description:"Key of the HMAC. It is a template."`
+	Algorithm string `yaml:"algorithm,omitempty" json:"algorithm,omitempty" description:"Hash function of the HMAC, sha256 by default."`
+	Encoding  string `yaml:"encoding,omitempty" json:"encoding,omitempty" description:"Encoding of the signature, hex by default."`
+	Prefix    string `yaml:"prefix,omitempty" json:"prefix,omitempty" description:"Prefix of the header value, e.g. sha256=."`
+
+	// Inherited is set on signing applied from a catalog manifest. Hooks
+	// are sent unsigned if an inherited secret is empty, as catalogs sign
+	// hooks for users who may not have the secret.
+	Inherited bool `yaml:"-" json:"-"`
+}
+
+// unsignedWarnings are the headers of inherited signing that were skipped
+// and warned about, so that repeated hooks only warn once.
+var unsignedWarnings sync.Map
+
+// skip reports whether the rendered signing is skipped, warning the first
+// time it is.
+func (s *Signing) skip() bool {
+	if !s.Inherited || s.Secret != "" {
+		return false
+	}
+	if _, warned := unsignedWarnings.LoadOrStore(s.Header, true); !warned {
+		log.Printf("signing secret for %s from the catalog manifest is empty, sending hooks without %[1]s", s.Header)
+	}
+	return true
+}
+
+// RedactedSignature is sent instead of the signature of hooks passed to
+// RedactSignatures.
+const RedactedSignature = "REDACTED"
+
+// RedactSignatures replaces the signing settings of signed hooks with a
+// RedactedSignature header, so that exported requests can be shared without a
+// signature computed from the secret.
+func RedactSignatures(hooks []*Hook) {
+	for _, h := range hooks {
+		if h.Signing == nil {
+			continue
+		}
+		headers := make(http.Header, len(h.Headers)+1)
+		for k, v := range h.Headers {
+			headers[k] = v
+		}
+		headers.Set(h.Signing.Header, h.Signing.Prefix+RedactedSignature)
+		h.Headers = headers
+		h.Signing = nil
+	}
+}
+
+// Validate checks that the header is set and that the algorithm and the
+// encoding are supported.
+func (s *Signing) Validate() error {
+	if errs := s.problems(); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// problems returns every reason the settings are invalid, so that lint can
+// report all of them at once.
+func (s *Signing) problems() []error {
+	var errs []error
+	if s.Header == "" {
+		errs = append(errs, errors.New("signing header is required"))
+	}
+	if _, ok := SigningAlgorithms[s.algorithm()]; !ok {
+		errs = append(errs, fmt.Errorf("unknown signing algorithm %q, expected one of: %s", s.Algorithm, strings.Join(sortedAlgorithms(), ", ")))
+	}
+	if _, ok := signingEncodings[s.encoding()]; !ok {
+		errs = append(errs, fmt.Errorf("unknown signing encoding %q, expected one of: base64, hex", s.Encoding))
+	}
+	return errs
+}
+
+func (s *Signing) algorithm() string {
+	if s.Algorithm == "" {
+		return "sha256"
+	}
+	return s.Algorithm
+}
+
+func (s *Signing) encoding() string {
+	if s.Encoding == "" {
+		return "hex"
+	}
+	return s.Encoding
+}
+
+// Sign sets the signature header of body. The secret must already be
+// rendered.
+func (s *Signing) Sign(body string, headers http.Header) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	if s.Secret == "" {
+		return fmt.Errorf("signing secret for %s is empty", s.Header)
+	}
+	mac := hmac.New(SigningAlgorithms[s.algorithm()], []byte(s.Secret))
+	mac.Write([]byte(body))
+	headers.Set(s.Header, s.Prefix+signingEncodings[s.encoding()](mac.Sum(nil)))
+	return nil
+}
+
+func sortedAlgorithms() []string {
+	names := make([]string, 0, len(SigningAlgorithms))
+	for a := range SigningAlgorithms {
+		names = append(names, a)
+	}
+	sort.Strings(names)
+	return names
+}
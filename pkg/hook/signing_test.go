@@ -0,0 +1,111 @@
+package hook
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+)
+
+func TestSign(t *testing.T) {
+	tests := []struct {
+		name    string
+		signing *Signing
+		body    string
+		want    string
+	}{
+		{
+			name:    "github",
+			signing: &Signing{Header: "X-Hub-Signature-256", Prefix: "sha256=", Secret: "It's a Secret to Everybody"},
+			body:    "Hello, World!",
+			want:    "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17",
+		},
+		{
+			name:    "shopify",
+			signing: &Signing{Header: "X-Shopify-Hmac-Sha256", Encoding: "base64", Secret: "shpss"},
+			body:    `{"id":1}`,
+			want:    "hFJO6VJKe4kBKbdqB4Y0kbIIEQ8QBuyXm5SaI/IGi9o=",
+		},
+		{
+			name:    "sha1",
+			signing: &Signing{Header: "X-Hub-Signature", Algorithm: "sha1", Prefix: "sha1=", Secret: "abc"},
+			body:    "body",
+			want:    "sha1=ca9d7a83e272547f66df930c9fcf5b7530682b3a",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			headers := make(http.Header)
+			if err := tc.signing.Sign(tc.body, headers); err != nil {
+				t.Fatal(err)
+			}
+			if got := headers.Get(tc.signing.Header); got != tc.want {
+				t.Errorf("got %s, want %s", got, tc.want)
+			}
+		})
+	}
+
+	for _, s := range []*Signing{
+		{Secret: "a"},
+		{Header: "X", Secret: "a", Algorithm: "md5"},
+		{Header: "X", Secret: "a", Encoding: "base32"},
+		{Header: "X"},
+	} {
+		if err := s.Sign("", make(http.Header)); err == nil {
+			t.Errorf("Sign(%+v): expected error", s)
+		}
+	}
+}
+
+func TestFireSigned(t *testing.T) {
+	os.Setenv("HOOK_TEST_SECRET", "It's a Secret to Everybody")
+	defer os.Unsetenv("HOOK_TEST_SECRET")
+
+	for _, secret := range []string{`{{ env "HOOK_TEST_SECRET" }}`, "{{ .secret }}"} {
+		h := &Hook{
+			Method:  http.MethodPost,
+			Body:    "Hello, {{ .name }}!",
+			Vars:    map[string]string{"name": "World", "secret": "It's a Secret to Everybody"},
+			Signing: &Signing{Header: "X-Hub-Signature-256", Prefix: "sha256=", Secret: secret},
+		}
+		r, err := h.toRequest("http://localhost")
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != "Hello, World!" {
+			t.Errorf("body = %q", b)
+		}
+		want := "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17"
+		if got := r.Header.Get("X-Hub-Signature-256"); got != want {
+			t.Errorf("%s: signature = %s, want %s", secret, got, want)
+		}
+		if h.Signing.Secret != secret {
+			t.Errorf("hook was modified: %+v", h.Signing)
+		}
+	}
+}
+
+func TestFireInheritedSigning(t *testing.T) {
+	os.Unsetenv("HOOK_TEST_SECRET")
+
+	// Catalog signing is skipped if the user doesn't have the secret.
+	s := &Signing{Header: "X-Hub-Signature-256", Secret: `{{ env "HOOK_TEST_SECRET" }}`, Inherited: true}
+	h := &Hook{Method: http.MethodPost, Body: "hi", Signing: s}
+	r, err := h.toRequest("http://localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.Header.Get("X-Hub-Signature-256"); got != "" {
+		t.Errorf("got signature %s, want none", got)
+	}
+
+	// Signing of the hook itself requires the secret.
+	h.Signing = &Signing{Header: s.Header, Secret: s.Secret}
+	if _, err := h.toRequest("http://localhost"); err == nil {
+		t.Error("expected an error for an empty secret")
+	}
+}
@@ -0,0 +1,264 @@
+package hook
+
+import (
+	"container/heap"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DeliveryPolicy describes how a webhook provider delivers events and retries
+// failed deliveries. A delivery fails if the request errors or the response
+// is not 2xx.
+//
+// Backoff durations are compressed compared to the real providers (which
+// retry over hours or days) so that a simulation finishes quickly. Use
+// Simulator.Speed to scale them further.
+type DeliveryPolicy struct {
+	Name string
+	// Retries is the maximum number of times a failed delivery is retried.
+	Retries int
+	// Backoff is the wait before the first retry. Each subsequent retry waits
+	// Multiplier times longer than the last, up to MaxBackoff.
+	Backoff    time.Duration
+	Multiplier float64
+	MaxBackoff time.Duration
+}
+
+var (
+	// DeliveryPolicies are the built-in provider delivery policies.
+	DeliveryPolicies = map[string]*DeliveryPolicy{
+		// GitHub does not automatically redeliver failed webhooks.
+		"github": {
+			Name: "github",
+		},
+		// Stripe retries with exponential backoff for up to three days.
+		"stripe": {
+			Name:       "stripe",
+			Retries:    8,
+			Backoff:    time.Second,
+			Multiplier: 2,
+			MaxBackoff: time.Minute,
+		},
+		// Shopify retries 19 times over 48 hours.
+		"shopify": {
+			Name:       "shopify",
+			Retries:    19,
+			Backoff:    time.Second,
+			Multiplier: 1.5,
+			MaxBackoff: 30 * time.Second,
+		},
+	}
+)
+
+// GetDeliveryPolicy returns the named built-in policy.
+func GetDeliveryPolicy(name string) (*DeliveryPolicy, error) {
+	p, ok := DeliveryPolicies[name]
+	if !ok {
+		names := make([]string, 0, len(DeliveryPolicies))
+		for n := range DeliveryPolicies {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown delivery policy %q, expected one of: %s", name, strings.Join(names, ", "))
+	}
+	return p, nil
+}
+
+// backoff returns the wait before the given retry (starting at 1).
+func (p *DeliveryPolicy) backoff(retry int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < retry; i++ {
+		d = time.Duration(float64(d) * p.Multiplier)
+		if p.MaxBackoff > 0 && d > p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	return d
+}
+
+// Delivery is a single attempt to deliver an event.
+type Delivery struct {
+	// Event is the index of the hook being delivered. Duplicates and retries
+	// of the same event share the same Event.
+	Event int
+	// Attempt is the delivery attempt, starting at 1.
+	Attempt int
+	// Duplicate is set if this delivery is an injected duplicate.
+	Duplicate bool
+	// At is when the attempt was made relative to the start of the simulation.
+	At       time.Duration
+	Status   int
+	Err      error
+	Duration time.Duration
+
+	hook *Hook
+	due  time.Duration
+	seq  int
+}
+
+// Failed reports whether the provider would consider the delivery failed.
+func (d *Delivery) Failed() bool {
+	return d.Err != nil || d.Status < 200 || d.Status > 299
+}
+
+// Timeline is the ordered list of delivery attempts made in a simulation.
+type Timeline []*Delivery
+
+// Print writes a human readable version of the timeline to w.
+func (t Timeline) Print(w io.Writer) {
+	for _, d := range t {
+		result := fmt.Sprint(d.Status)
+		if d.Err != nil {
+			result = fmt.Sprintf("error: %v", d.Err)
+		}
+		var notes []string
+		if d.Duplicate {
+			notes = append(notes, "duplicate")
+		}
+		if d.Attempt > 1 {
+			notes = append(notes, "retry")
+		}
+		note := ""
+		if len(notes) > 0 {
+			note = fmt.Sprintf(" (%s)", strings.Join(notes, ", "))
+		}
+		fmt.Fprintf(w, "%10s  event %d  attempt %d  %s%s\n", d.At.Round(time.Millisecond), d.Event, d.Attempt, result, note)
+	}
+}
+
+// deliveryQueue orders pending deliveries by due time, then insertion order.
+type deliveryQueue []*Delivery
+
+func (q deliveryQueue) Len() int { return len(q) }
+func (q deliveryQueue) Less(i, j int) bool {
+	if q[i].due != q[j].due {
+		return q[i].due < q[j].due
+	}
+	return q[i].seq < q[j].seq
+}
+func (q deliveryQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *deliveryQueue) Push(x interface{}) { *q = append(*q, x.(*Delivery)) }
+func (q *deliveryQueue) Pop() interface{} {
+	old := *q
+	d := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return d
+}
+
+// Simulator delivers hooks the way a webhook provider would, retrying
+// failures according to a DeliveryPolicy and optionally injecting duplicate
+// and out of order deliveries.
+type Simulator struct {
+	// Client is used for all requests. If nil, http.DefaultClient is used.
+	Client *http.Client
+	Policy *DeliveryPolicy
+	// Duplicates is the probability (0-1) that an event is delivered twice.
+	Duplicates float64
+	// Shuffle randomizes the order events are first delivered in.
+	Shuffle bool
+	// Speed divides the policy backoff durations. Values less than or equal
+	// to 0 are treated as 1.
+	Speed float64
+	// Rand is the source of randomness for duplicates and shuffling. If nil,
+	// a time seeded source is used.
+	Rand *rand.Rand
+}
+
+// Run delivers the hooks to target, calling handle (if set) after each
+// attempt, and returns the full delivery timeline.
+//
+// Each hook is rendered once, so duplicates and retries of an event send
+// identical requests, as a real provider would.
+func (s *Simulator) Run(target string, hooks []*Hook, handle func(*Delivery)) (Timeline, error) {
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	policy := s.Policy
+	if policy == nil {
+		policy = DeliveryPolicies["github"]
+	}
+	speed := s.Speed
+	if speed <= 0 {
+		speed = 1
+	}
+	rnd := s.Rand
+	if rnd == nil {
+		rnd = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+
+	var initial []*Delivery
+	for i, h := range hooks {
+		rh, err := h.render()
+		if err != nil {
+			return nil, err
+		}
+		initial = append(initial, &Delivery{Event: i, Attempt: 1, hook: rh})
+		if s.Duplicates > 0 && rnd.Float64() < s.Duplicates {
+			initial = append(initial, &Delivery{Event: i, Attempt: 1, Duplicate: true, hook: rh})
+		}
+	}
+	if s.Shuffle {
+		rnd.Shuffle(len(initial), func(i, j int) {
+			initial[i], initial[j] = initial[j], initial[i]
+		})
+	}
+
+	q := &deliveryQueue{}
+	seq := 0
+	for _, d := range initial {
+		d.seq = seq
+		seq++
+		heap.Push(q, d)
+	}
+
+	var timeline Timeline
+	start := time.Now()
+	for q.Len() > 0 {
+		d := heap.Pop(q).(*Delivery)
+		time.Sleep(time.Until(start.Add(d.due)))
+
+		d.At = time.Since(start)
+		d.Status, d.Err = deliver(client, target, d.hook)
+		d.Duration = time.Since(start) - d.At
+		timeline = append(timeline, d)
+		if handle != nil {
+			handle(d)
+		}
+
+		if d.Failed() && d.Attempt <= policy.Retries {
+			wait := time.Duration(float64(policy.backoff(d.Attempt)) / speed)
+			heap.Push(q, &Delivery{
+				Event:     d.Event,
+				Attempt:   d.Attempt + 1,
+				Duplicate: d.Duplicate,
+				hook:      d.hook,
+				due:       time.Since(start) + wait,
+				seq:       seq,
+			})
+			seq++
+		}
+	}
+	return timeline, nil
+}
+
+// deliver sends an already rendered hook, returning the response status.
+func deliver(client *http.Client, target string, h *Hook) (int, error) {
+	r, err := h.buildRequest(target)
+	if err != nil {
+		return 0, err
+	}
+	res, err := client.Do(r)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	io.Copy(ioutil.Discard, res.Body)
+	return res.StatusCode, nil
+}
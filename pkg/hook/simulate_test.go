@@ -0,0 +1,163 @@
+package hook
+
+import (
+	"bytes"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// flakyServer fails the first n requests for each distinct body.
+type flakyServer struct {
+	mu    sync.Mutex
+	fails int
+	seen  map[string]int
+}
+
+func (s *flakyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b := new(bytes.Buffer)
+	b.ReadFrom(r.Body)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seen[b.String()]++
+	if s.seen[b.String()] <= s.fails {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func TestGetDeliveryPolicy(t *testing.T) {
+	for _, name := range []string{"github", "stripe", "shopify"} {
+		if _, err := GetDeliveryPolicy(name); err != nil {
+			t.Errorf("GetDeliveryPolicy(%s): %v", name, err)
+		}
+	}
+	if _, err := GetDeliveryPolicy("pony-express"); err == nil {
+		t.Error("expected error for unknown policy")
+	}
+}
+
+func TestDeliveryPolicyBackoff(t *testing.T) {
+	p := &DeliveryPolicy{
+		Backoff:    time.Second,
+		Multiplier: 2,
+		MaxBackoff: 5 * time.Second,
+	}
+	var got []time.Duration
+	for i := 1; i <= 4; i++ {
+		got = append(got, p.backoff(i))
+	}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestSimulatorRetries(t *testing.T) {
+	for _, tc := range []struct {
+		policy string
+		fails  int
+		want   []int
+	}{
+		{
+			policy: "github",
+			fails:  2,
+			want:   []int{503},
+		},
+		{
+			policy: "stripe",
+			fails:  2,
+			want:   []int{503, 503, 200},
+		},
+	} {
+		t.Run(tc.policy, func(t *testing.T) {
+			s := &flakyServer{fails: tc.fails, seen: map[string]int{}}
+			srv := httptest.NewServer(s)
+			defer srv.Close()
+
+			sim := &Simulator{
+				Client: srv.Client(),
+				Policy: DeliveryPolicies[tc.policy],
+				Speed:  1000,
+			}
+			timeline, err := sim.Run(srv.URL, []*Hook{{Method: http.MethodPost, Body: "a"}}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []int
+			for i, d := range timeline {
+				got = append(got, d.Status)
+				if d.Attempt != i+1 {
+					t.Errorf("delivery %d: got attempt %d, want %d", i, d.Attempt, i+1)
+				}
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestSimulatorDuplicatesAndShuffle(t *testing.T) {
+	s := &flakyServer{seen: map[string]int{}}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	var hooks []*Hook
+	for _, b := range []string{"a", "b", "c", "d", "e"} {
+		hooks = append(hooks, &Hook{Method: http.MethodPost, Body: b})
+	}
+	// Templates are rendered once per event, so duplicates are identical.
+	hooks = append(hooks, &Hook{Method: http.MethodPost, Body: "{{ uuid }}"})
+
+	sim := &Simulator{
+		Client:     srv.Client(),
+		Duplicates: 1,
+		Shuffle:    true,
+		Rand:       rand.New(rand.NewSource(1)),
+	}
+	timeline, err := sim.Run(srv.URL, hooks, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(timeline) != 2*len(hooks) {
+		t.Fatalf("got %d deliveries, want %d", len(timeline), 2*len(hooks))
+	}
+	events := map[int]int{}
+	ordered := true
+	for i, d := range timeline {
+		events[d.Event]++
+		if i > 0 && d.Event < timeline[i-1].Event {
+			ordered = false
+		}
+	}
+	for i := range hooks {
+		if events[i] != 2 {
+			t.Errorf("event %d delivered %d times, want 2", i, events[i])
+		}
+	}
+	if ordered {
+		t.Error("expected deliveries to be shuffled")
+	}
+	for body, n := range s.seen {
+		if n != 2 {
+			t.Errorf("body %s received %d times, want 2", body, n)
+		}
+	}
+
+	b := new(bytes.Buffer)
+	timeline.Print(b)
+	if got := strings.Count(b.String(), "duplicate"); got != len(hooks) {
+		t.Errorf("got %d duplicates in timeline, want %d:\n%s", got, len(hooks), b)
+	}
+}
@@ -0,0 +1,202 @@
+package hook
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// TemplateFuncs are the functions available to hook templates in addition to
+// the hook's variables, e.g. {{ uuid }} or {{ .name }}.
+var TemplateFuncs = template.FuncMap{
+	"uuid":    newUUID,
+	"now":     func() string { return time.Now().UTC().Format(time.RFC3339) },
+	"unix":    func() int64 { return time.Now().Unix() },
+	"randInt": randInt,
+	"env":     env,
+}
+
+// EnvPrefix is the prefix of the environment variables env can read in the
+// body, header values and param values of hooks. Hooks are shared through
+// catalogs, so they must not be able to send other variables, such as cloud
+// credentials, to the target. Signing secrets can read any variable, as they
+// are only used to compute signatures.
+const EnvPrefix = "HOOK_"
+
+// secretFuncs override TemplateFuncs in signing secrets.
+var secretFuncs = template.FuncMap{"env": os.Getenv}
+
+// env returns the value of an environment variable starting with EnvPrefix.
+func env(name string) (string, error) {
+	if !strings.HasPrefix(name, EnvPrefix) {
+		return "", fmt.Errorf("env %s: only variables starting with %s can be read outside of signing secrets", name, EnvPrefix)
+	}
+	return os.Getenv(name), nil
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+// randInt returns a random integer in [min, max).
+func randInt(min, max int64) (int64, error) {
+	if max <= min {
+		return 0, fmt.Errorf("randInt: max (%d) must be greater than min (%d)", max, min)
+	}
+	n, err := rand.Int(rand.Reader, big.NewInt(max-min))
+	if err != nil {
+		return 0, err
+	}
+	return min + n.Int64(), nil
+}
+
+// isTemplate reports whether s contains template actions.
+func isTemplate(s string) bool {
+	return strings.Contains(s, "{{")
+}
+
+// isHookTemplate reports whether s parses as a hook template, as opposed to
+// literal "{{" such as in Mustache or Handlebars templates.
+func isHookTemplate(s string) bool {
+	if !isTemplate(s) {
+		return false
+	}
+	_, err := template.New("").Funcs(TemplateFuncs).Parse(s)
+	return err == nil
+}
+
+// execute renders s as a template using the given variables, with funcs
+// overriding TemplateFuncs. Strings without template actions are returned as
+// is.
+func execute(s string, vars map[string]string, funcs template.FuncMap) (string, error) {
+	if !isTemplate(s) {
+		return s, nil
+	}
+	t, err := template.New("").Funcs(TemplateFuncs).Funcs(funcs).Option("missingkey=error").Parse(s)
+	if err != nil {
+		return "", err
+	}
+	if vars == nil {
+		vars = map[string]string{}
+	}
+	buf := new(bytes.Buffer)
+	if err := t.Execute(buf, vars); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// templated reports whether the body, header values and param values of the
+// hook are templates. Hooks opt in with Template or by setting Vars, so that
+// payloads containing literal "{{", e.g. recorded Mustache or Liquid
+// templates, are sent as is.
+func (h *Hook) templated() bool {
+	return h.Template || len(h.Vars) > 0
+}
+
+// hasTemplates reports whether the body, header values or param values of
+// the hook contain template actions.
+func (h *Hook) hasTemplates() bool {
+	if isTemplate(h.Body) {
+		return true
+	}
+	for _, values := range h.Headers {
+		for _, v := range values {
+			if isTemplate(v) {
+				return true
+			}
+		}
+	}
+	for _, values := range h.Params {
+		for _, v := range values {
+			if isTemplate(v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// render returns a copy of the hook with templates in the body, header values
+// and param values executed if the hook is templated, and the signing secret
+// executed. The receiver is not modified.
+func (h *Hook) render() (*Hook, error) {
+	out := *h
+
+	// Headers and params are copied even if they are sent verbatim, as
+	// CloudEvents attributes may be added to them.
+	exec := func(s string) (string, error) {
+		if !h.templated() {
+			return s, nil
+		}
+		return execute(s, h.Vars, nil)
+	}
+
+	var err error
+	out.Body, err = exec(h.Body)
+	if err != nil {
+		return nil, fmt.Errorf("body: %v", err)
+	}
+
+	if h.Headers != nil {
+		out.Headers = make(http.Header, len(h.Headers))
+		for k, values := range h.Headers {
+			rendered, err := executeAll(values, exec)
+			if err != nil {
+				return nil, fmt.Errorf("header %s: %v", k, err)
+			}
+			out.Headers[k] = rendered
+		}
+	}
+
+	if h.Params != nil {
+		out.Params = make(url.Values, len(h.Params))
+		for k, values := range h.Params {
+			rendered, err := executeAll(values, exec)
+			if err != nil {
+				return nil, fmt.Errorf("param %s: %v", k, err)
+			}
+			out.Params[k] = rendered
+		}
+	}
+
+	if h.Signing != nil {
+		s := *h.Signing
+		s.Secret, err = execute(s.Secret, h.Vars, secretFuncs)
+		if err != nil {
+			return nil, fmt.Errorf("signing secret: %v", err)
+		}
+		out.Signing = &s
+	}
+
+	if err := out.populateCloudEvent(); err != nil {
+		return nil, fmt.Errorf("cloudevents: %v", err)
+	}
+	return &out, nil
+}
+
+func executeAll(values []string, exec func(string) (string, error)) ([]string, error) {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		s, err := exec(v)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
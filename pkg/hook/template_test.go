@@ -0,0 +1,122 @@
+package hook
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRender(t *testing.T) {
+	h := &Hook{
+		Method: http.MethodPost,
+		Headers: http.Header{
+			"X-Event": []string{"{{ .event }}"},
+			"Plain":   []string{"value"},
+		},
+		Body: `{"event":"{{ .event }}","repo":"{{ .repo }}"}`,
+		Params: url.Values{
+			"repo": []string{"{{ .repo }}"},
+		},
+		Vars: map[string]string{
+			"event": "push",
+			"repo":  "eddiezane/hook",
+		},
+	}
+
+	got, err := h.render()
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+
+	want := &Hook{
+		Method: http.MethodPost,
+		Headers: http.Header{
+			"X-Event": []string{"push"},
+			"Plain":   []string{"value"},
+		},
+		Body: `{"event":"push","repo":"eddiezane/hook"}`,
+		Params: url.Values{
+			"repo": []string{"eddiezane/hook"},
+		},
+		Vars: h.Vars,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+
+	// The original hook should not be modified.
+	if h.Body != `{"event":"{{ .event }}","repo":"{{ .repo }}"}` {
+		t.Errorf("render modified hook body: %s", h.Body)
+	}
+}
+
+func TestRenderFuncs(t *testing.T) {
+	h := &Hook{Body: `{{ uuid }} {{ randInt 1 2 }}`, Template: true}
+	got, err := h.render()
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	re := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12} 1$`)
+	if !re.MatchString(got.Body) {
+		t.Errorf("unexpected body %s", got.Body)
+	}
+}
+
+func TestRenderEnv(t *testing.T) {
+	os.Setenv("HOOK_TEST_VALUE", "value")
+	defer os.Unsetenv("HOOK_TEST_VALUE")
+	os.Setenv("TEST_CREDENTIALS", "credentials")
+	defer os.Unsetenv("TEST_CREDENTIALS")
+
+	h := &Hook{Body: `{{ env "HOOK_TEST_VALUE" }}`, Template: true}
+	got, err := h.render()
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if got.Body != "value" {
+		t.Errorf("got body %q, want value", got.Body)
+	}
+
+	// Other variables can only be read by signing secrets.
+	for _, h := range []*Hook{
+		{Body: `{{ env "TEST_CREDENTIALS" }}`, Template: true},
+		{Headers: http.Header{"X-Leak": {`{{ env "TEST_CREDENTIALS" }}`}}, Template: true},
+		{Params: url.Values{"leak": {`{{ env "TEST_CREDENTIALS" }}`}}, Template: true},
+	} {
+		if _, err := h.render(); err == nil {
+			t.Errorf("render(%+v): expected an error", h)
+		}
+	}
+	h = &Hook{Signing: &Signing{Header: "X-Signature", Secret: `{{ env "TEST_CREDENTIALS" }}`}}
+	if got, err = h.render(); err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if got.Signing.Secret != "credentials" {
+		t.Errorf("got secret %q, want credentials", got.Signing.Secret)
+	}
+}
+
+func TestRenderMissingVar(t *testing.T) {
+	h := &Hook{Body: `{{ .missing }}`, Template: true}
+	if _, err := h.render(); err == nil {
+		t.Error("expected error for missing variable")
+	}
+}
+
+func TestRenderVerbatim(t *testing.T) {
+	h := &Hook{
+		Body:    `Hi {{name}}, {{ .missing }}`,
+		Headers: http.Header{"X-Template": []string{"{{#each}}"}},
+	}
+	got, err := h.render()
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if diff := cmp.Diff(h, got); diff != "" {
+		t.Error(diff)
+	}
+}
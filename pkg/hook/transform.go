@@ -76,6 +76,11 @@ func DecodeOption(t Transformer, paths ...string) Option {
 }
 
 func (t *decodeOption) Apply(h *Hook) error {
+	// Nothing to decode, e.g. GET requests.
+	if h.Body == "" {
+		return nil
+	}
+
 	// Set transform metadata in hook.
 	if h.Transform == nil {
 		h.Transform = make(map[TransformStrategy][]string)
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"io/ioutil"
+	"log"
+
+	"github.com/eddiezane/hook/pkg/hook"
+)
+
+func main() {
+	b, err := hook.Schema()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile("../../schema/hook.schema.json", b, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,124 @@
+// snapshotgen generates the snapshot of the default catalog that is embedded
+// in hook for offline use. It clones the default catalog, or snapshots a
+// local git checkout of it if one is given, and records the commit it was
+// taken at:
+//
+//	go run . [dir]
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/eddiezane/hook/pkg/hook"
+	"github.com/spf13/viper"
+)
+
+const out = "../../pkg/hook/catalog_snapshot_gen.go"
+
+func main() {
+	var dir, rev string
+	if len(os.Args) > 1 {
+		dir = os.Args[1]
+		b, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+		if err != nil {
+			log.Fatalf("%s isn't a git checkout of the default catalog: %v", dir, err)
+		}
+		rev = strings.TrimSpace(string(b))
+	} else {
+		cache, err := ioutil.TempDir("", "snapshotgen")
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer os.RemoveAll(cache)
+		viper.Set("cache", cache)
+
+		rc := *hook.DefaultCatalog
+		rc.Name = "default"
+		if err := rc.Clone(); err != nil {
+			log.Fatal(err)
+		}
+		if rev, err = rc.Commit(); err != nil {
+			log.Fatal(err)
+		}
+		dir = rc.Path()
+	}
+	if rev == "" {
+		log.Fatal("the commit of the default catalog is unknown")
+	}
+
+	files, err := readCatalog(hook.DirCatalog(dir))
+	if err != nil {
+		log.Fatal(err)
+	}
+	b, err := generate(rev, files)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile(out, b, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// readCatalog returns the hook files and the manifest of the catalog.
+func readCatalog(c hook.Catalog) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	read := func(p string) error {
+		f, err := c.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		files[p], err = ioutil.ReadAll(f)
+		return err
+	}
+	if err := hook.WalkCatalog(c, "", read); err != nil {
+		return nil, err
+	}
+	if _, err := c.Stat(hook.ManifestFile); err == nil {
+		if err := read(hook.ManifestFile); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+func generate(rev string, files map[string][]byte) ([]byte, error) {
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by tools/snapshotgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package hook\n\n")
+	fmt.Fprintf(&buf, "// defaultSnapshot is the snapshot of the default catalog embedded in hook.\n")
+	fmt.Fprintf(&buf, "var defaultSnapshot = &snapshotCatalog{\n")
+	fmt.Fprintf(&buf, "Revision: %q,\n", rev)
+	fmt.Fprintf(&buf, "MemCatalog: MemCatalog{\n")
+	for _, p := range paths {
+		fmt.Fprintf(&buf, "%q: []byte(%s),\n", p, quote(files[p]))
+	}
+	fmt.Fprintf(&buf, "},\n}\n")
+	return format.Source(buf.Bytes())
+}
+
+// quote returns b as a raw string literal if it can be written as one, so
+// that the generated file is readable.
+func quote(b []byte) string {
+	s := string(b)
+	if strings.ContainsAny(s, "`\r\x00") || !utf8.ValidString(s) {
+		return strconv.Quote(s)
+	}
+	return "`" + s + "`"
+}